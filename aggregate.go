@@ -0,0 +1,153 @@
+package connectlog
+
+import (
+	"context"
+	"log/slog"
+	"maps"
+	"math"
+	"slices"
+	"sync"
+	"time"
+)
+
+// Duration histogram buckets grow exponentially from histogramMin, so the
+// percentiles have a relative error of about 5% within a fixed amount of
+// memory per procedure. Durations above the last bucket (about 10 hours)
+// are counted in it.
+const (
+	histogramMin     = time.Microsecond
+	histogramGrowth  = 1.1
+	histogramBuckets = 256
+)
+
+// durationHistogram is a memory-bounded estimator of duration percentiles.
+type durationHistogram struct {
+	counts [histogramBuckets]uint64
+	total  uint64
+}
+
+// histogramBucket returns the index of the bucket holding d: bucket 0 holds
+// durations up to histogramMin, bucket n those in
+// (histogramMin*growth^(n-1), histogramMin*growth^n].
+func histogramBucket(d time.Duration) int {
+	if d <= histogramMin {
+		return 0
+	}
+	n := int(math.Ceil(math.Log(float64(d)/float64(histogramMin)) / math.Log(histogramGrowth)))
+	return min(n, histogramBuckets-1)
+}
+
+func (h *durationHistogram) add(d time.Duration) {
+	h.counts[histogramBucket(d)]++
+	h.total++
+}
+
+// quantile returns the estimated duration below which the fraction q of
+// the recorded durations fall, as the geometric middle of its bucket.
+func (h *durationHistogram) quantile(q float64) time.Duration {
+	if h.total == 0 {
+		return 0
+	}
+
+	rank := max(uint64(math.Ceil(q*float64(h.total))), 1)
+	var seen uint64
+	for n, count := range h.counts {
+		seen += count
+		if seen < rank {
+			continue
+		}
+		if n == 0 {
+			return histogramMin
+		}
+		return time.Duration(float64(histogramMin) * math.Pow(histogramGrowth, float64(n)-0.5))
+	}
+	return 0 // unreachable: the counts add up to total
+}
+
+// procedureStats aggregates the calls to a procedure within a window.
+type procedureStats struct {
+	calls     uint64
+	errors    uint64
+	durations durationHistogram
+}
+
+// aggregator collects per-procedure statistics for WithAggregationWindow.
+type aggregator struct {
+	mu         sync.Mutex
+	procedures map[string]*procedureStats
+}
+
+// record adds a completed call to the current window.
+func (a *aggregator) record(procedure string, failed bool, duration time.Duration) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	stats := a.procedures[procedure]
+	if stats == nil {
+		stats = new(procedureStats)
+		a.procedures[procedure] = stats
+	}
+	stats.calls++
+	if failed {
+		stats.errors++
+	}
+	stats.durations.add(duration)
+}
+
+// reset returns the statistics of the current window and starts a new one.
+func (a *aggregator) reset() map[string]*procedureStats {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	procedures := a.procedures
+	a.procedures = make(map[string]*procedureStats, len(procedures))
+	return procedures
+}
+
+// aggregate records a completed call when WithAggregationWindow is enabled.
+func (i *LoggingInterceptor) aggregate(procedure string, err error, duration time.Duration) {
+	if i.aggregator != nil {
+		i.aggregator.record(procedure, resultCode(err) != 0, duration)
+	}
+}
+
+// startAggregation starts the goroutine logging the per-procedure summaries
+// every window. The last window is logged when the interceptor is closed.
+func (i *LoggingInterceptor) startAggregation(window time.Duration) {
+	i.aggregator = &aggregator{procedures: make(map[string]*procedureStats)}
+	i.goBackground(func(ctx context.Context) {
+		ticker := time.NewTicker(window)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				i.logAggregates(ctx)
+			case <-ctx.Done():
+				i.logAggregates(context.WithoutCancel(ctx))
+				return
+			}
+		}
+	})
+}
+
+// logAggregates writes an "rpc summary" record for every procedure called
+// during the window, in procedure order.
+func (i *LoggingInterceptor) logAggregates(ctx context.Context) {
+	procedures := i.aggregator.reset()
+	for _, name := range slices.Sorted(maps.Keys(procedures)) {
+		stats := procedures[name]
+		info := i.procedureInfo(name)
+		attrs := make([]slog.Attr, 0, len(info.attrs)+3)
+		attrs = append(attrs, info.attrs...)
+		attrs = append(attrs,
+			slog.Uint64("calls", stats.calls),
+			slog.Uint64("errors", stats.errors),
+			slog.Group("duration",
+				slog.Duration("p50", i.roundDuration(stats.durations.quantile(0.50))),
+				slog.Duration("p95", i.roundDuration(stats.durations.quantile(0.95))),
+				slog.Duration("p99", i.roundDuration(stats.durations.quantile(0.99))),
+			),
+		)
+		i.baseLogger(info.service).LogAttrs(ctx, slog.LevelInfo, "rpc summary", attrs...)
+	}
+}
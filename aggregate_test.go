@@ -0,0 +1,71 @@
+package connectlog
+
+import (
+	"context"
+	"log/slog"
+	"testing"
+	"time"
+
+	"connectrpc.com/connect"
+)
+
+func TestDurationHistogramQuantile(t *testing.T) {
+	var h durationHistogram
+	for n := range 1000 {
+		h.add(time.Duration(n+1) * time.Millisecond)
+	}
+
+	tests := []struct {
+		q        float64
+		expected time.Duration
+	}{
+		{q: 0.50, expected: 500 * time.Millisecond},
+		{q: 0.95, expected: 950 * time.Millisecond},
+		{q: 0.99, expected: 990 * time.Millisecond},
+	}
+	for _, tt := range tests {
+		got := h.quantile(tt.q)
+		if diff := float64(got-tt.expected) / float64(tt.expected); diff < -0.05 || diff > 0.05 {
+			t.Errorf("p%.0f: expected about %v, got %v", tt.q*100, tt.expected, got)
+		}
+	}
+
+	if got := new(durationHistogram).quantile(0.5); got != 0 {
+		t.Errorf("expected 0 for an empty histogram, got %v", got)
+	}
+}
+
+func TestWithAggregationWindow(t *testing.T) {
+	logger, buf := newTestLogger(slog.LevelInfo)
+	// Calls are aggregated even when their own logs are sampled out
+	interceptor := NewLoggingInterceptor(
+		WithLogger(logger),
+		WithAggregationWindow(time.Hour),
+		WithSampleRates(map[connect.Code]float64{}, 0),
+	)
+
+	for n := range 10 {
+		_, _ = callUnary(t, interceptor, newTestRequest(testProcedure, &struct{}{}), func(context.Context, connect.AnyRequest) (connect.AnyResponse, error) {
+			if n%5 == 0 {
+				return nil, connect.NewError(connect.CodeInternal, nil)
+			}
+			return connect.NewResponse(&struct{}{}), nil
+		})
+	}
+	_ = interceptor.Close() // logs the last window
+
+	records := logRecords(t, buf)
+	if len(records) != 1 {
+		t.Fatalf("expected only the summary record, got %v", records)
+	}
+	record := findRecord(t, records, "rpc summary")
+	if record["method"] != "Call" || record["calls"] != float64(10) || record["errors"] != float64(2) {
+		t.Errorf("expected 10 calls with 2 errors for Call, got %v", record)
+	}
+	durations, _ := record["duration"].(map[string]any)
+	for _, key := range []string{"p50", "p95", "p99"} {
+		if _, ok := durations[key].(float64); !ok {
+			t.Errorf("expected duration %s, got %v", key, durations)
+		}
+	}
+}
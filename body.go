@@ -0,0 +1,351 @@
+package connectlog
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"reflect"
+	"regexp"
+	"slices"
+	"strconv"
+
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/encoding/prototext"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"gopkg.in/yaml.v3"
+)
+
+// bodyAttr builds the log attribute for a request or response payload
+// according to the configured body logging mode.
+//
+// Payloads implementing slog.LogValuer control their own rendering once
+// redacted, unless WithLogBodyShape applies to them, and take precedence
+// over WithMaxBodyFields, WithBodyAsYAML and WithBodyAsJSON. With
+// WithLazyBodies the rendering is deferred until a handler resolves the
+// value.
+func (i *LoggingInterceptor) bodyAttr(key string, payload any) slog.Attr {
+	if i.options.LazyBodies && !i.options.LogBodyShape {
+		return slog.Any(key, lazyBody{interceptor: i, key: key, payload: payload})
+	}
+	return i.renderBody(key, payload)
+}
+
+// lazyBody renders a body only when a handler resolves it, so that records
+// dropped by the handler don't pay for redaction and marshaling.
+type lazyBody struct {
+	interceptor *LoggingInterceptor
+	key         string
+	payload     any
+}
+
+// LogValue implements slog.LogValuer.
+func (b lazyBody) LogValue() slog.Value {
+	return b.interceptor.renderBody(b.key, b.payload).Value
+}
+
+// renderBody applies the body logging mode to payload.
+func (i *LoggingInterceptor) renderBody(key string, payload any) slog.Attr {
+	payload = i.redactor.RedactBody(payload)
+
+	if i.options.LogBodyShape {
+		if fields, ok := bodyShape(payload); ok {
+			if i.options.MaxBodyFields > 0 && len(fields) > i.options.MaxBodyFields {
+				more := len(fields) - i.options.MaxBodyFields
+				fields = append(fields[:i.options.MaxBodyFields:i.options.MaxBodyFields], "_more: "+strconv.Itoa(more))
+			}
+			return slog.Any(key+"_fields", fields)
+		}
+	}
+
+	if _, ok := payload.(slog.LogValuer); ok {
+		return slog.Any(key, payload)
+	}
+
+	if i.options.MaxBodyFields > 0 {
+		if attr, ok := limitedBodyAttr(key, payload, i.options.MaxBodyFields); ok {
+			return attr
+		}
+	}
+
+	if i.options.BodyAsYAML {
+		return slog.String(key, yamlBody(payload))
+	}
+	if i.options.BodyAsJSON {
+		return slog.Any(key, jsonBody(payload))
+	}
+
+	return slog.Any(key, payload)
+}
+
+// yamlBody renders payload as a block-style YAML document for
+// WithBodyAsYAML. Fields keep the order of the JSON rendering.
+func yamlBody(payload any) string {
+	var node yaml.Node
+	if err := yaml.Unmarshal(jsonBody(payload), &node); err != nil {
+		return fmt.Sprint(payload)
+	}
+	blockStyle(&node)
+
+	data, err := yaml.Marshal(&node)
+	if err != nil {
+		return fmt.Sprint(payload)
+	}
+	return string(data)
+}
+
+// blockStyle clears the flow and quoting styles inherited from the JSON
+// source so that nested values are written one per line and strings are
+// quoted only when required.
+func blockStyle(node *yaml.Node) {
+	node.Style = 0
+	for _, child := range node.Content {
+		blockStyle(child)
+	}
+}
+
+// jsonBody renders payload as JSON for WithBodyAsJSON.
+func jsonBody(payload any) json.RawMessage {
+	var raw string
+	switch v := payload.(type) {
+	case proto.Message:
+		if data, err := protojson.Marshal(v); err == nil {
+			return data
+		}
+		raw = prototext.Format(v)
+	case json.RawMessage:
+		raw = string(v)
+	case []byte:
+		raw = string(v)
+	case string:
+		raw = v
+	default:
+		if data, err := json.Marshal(v); err == nil {
+			return data
+		}
+		raw = fmt.Sprint(v)
+	}
+
+	if json.Valid([]byte(raw)) {
+		return json.RawMessage(raw)
+	}
+	data, _ := json.Marshal(map[string]string{"raw": raw})
+	return data
+}
+
+// limitedBodyAttr renders the first maxFields populated fields of a proto
+// message as a group, followed by _more with the number of omitted fields.
+// It reports false when payload is not a message exceeding the limit.
+func limitedBodyAttr(key string, payload any, maxFields int) (slog.Attr, bool) {
+	msg, ok := payload.(proto.Message)
+	if !ok || !msg.ProtoReflect().IsValid() {
+		return slog.Attr{}, false
+	}
+
+	// Walk fields in declaration order, as bodyShape does
+	m := msg.ProtoReflect()
+	fields := m.Descriptor().Fields()
+	attrs := make([]any, 0, maxFields+1)
+	populated := 0
+	for idx := range fields.Len() {
+		fd := fields.Get(idx)
+		if !m.Has(fd) {
+			continue
+		}
+		populated++
+		if populated <= maxFields {
+			attrs = append(attrs, slog.Any(string(fd.Name()), protoFieldValue(fd, m.Get(fd))))
+		}
+	}
+	if populated <= maxFields {
+		return slog.Attr{}, false
+	}
+
+	attrs = append(attrs, slog.Int("_more", populated-maxFields))
+	return slog.Group(key, attrs...), true
+}
+
+// protoFieldValue converts a proto field value into a plain Go value that
+// log handlers can render.
+func protoFieldValue(fd protoreflect.FieldDescriptor, v protoreflect.Value) any {
+	switch {
+	case fd.IsList():
+		list := v.List()
+		values := make([]any, 0, list.Len())
+		for idx := range list.Len() {
+			values = append(values, protoScalarValue(fd, list.Get(idx)))
+		}
+		return values
+	case fd.IsMap():
+		values := make(map[string]any, v.Map().Len())
+		v.Map().Range(func(k protoreflect.MapKey, mv protoreflect.Value) bool {
+			values[k.String()] = protoScalarValue(fd.MapValue(), mv)
+			return true
+		})
+		return values
+	default:
+		return protoScalarValue(fd, v)
+	}
+}
+
+// protoScalarValue converts a single (non-repeated) proto value.
+func protoScalarValue(fd protoreflect.FieldDescriptor, v protoreflect.Value) any {
+	switch fd.Kind() {
+	case protoreflect.MessageKind, protoreflect.GroupKind:
+		return v.Message().Interface()
+	case protoreflect.EnumKind:
+		if value := fd.Enum().Values().ByNumber(v.Enum()); value != nil {
+			return string(value.Name())
+		}
+		return int32(v.Enum())
+	default:
+		return v.Interface()
+	}
+}
+
+// redactProtoValues redacts the string values of msg and its nested
+// messages matching any of patterns in place, and reports whether anything
+// was redacted.
+func redactProtoValues(msg protoreflect.Message, patterns []*regexp.Regexp) bool {
+	redacted := false
+	fields := msg.Descriptor().Fields()
+	for idx := range fields.Len() {
+		fd := fields.Get(idx)
+		if !msg.Has(fd) {
+			continue
+		}
+
+		switch {
+		case fd.IsMap():
+			m := msg.Mutable(fd).Map()
+			if fd.MapValue().Message() != nil {
+				m.Range(func(_ protoreflect.MapKey, v protoreflect.Value) bool {
+					redacted = redactProtoValues(v.Message(), patterns) || redacted
+					return true
+				})
+				continue
+			}
+			if fd.MapValue().Kind() != protoreflect.StringKind {
+				continue
+			}
+			var keys []protoreflect.MapKey
+			m.Range(func(k protoreflect.MapKey, v protoreflect.Value) bool {
+				if matchesAny(patterns, v.String()) {
+					keys = append(keys, k)
+				}
+				return true
+			})
+			for _, k := range keys {
+				m.Set(k, protoreflect.ValueOfString(redactedValue))
+				redacted = true
+			}
+		case fd.Message() != nil && fd.IsList():
+			list := msg.Mutable(fd).List()
+			for n := range list.Len() {
+				redacted = redactProtoValues(list.Get(n).Message(), patterns) || redacted
+			}
+		case fd.Message() != nil:
+			redacted = redactProtoValues(msg.Mutable(fd).Message(), patterns) || redacted
+		case fd.Kind() == protoreflect.StringKind && fd.IsList():
+			list := msg.Mutable(fd).List()
+			for n := range list.Len() {
+				if matchesAny(patterns, list.Get(n).String()) {
+					list.Set(n, protoreflect.ValueOfString(redactedValue))
+					redacted = true
+				}
+			}
+		case fd.Kind() == protoreflect.StringKind:
+			if matchesAny(patterns, msg.Get(fd).String()) {
+				msg.Set(fd, protoreflect.ValueOfString(redactedValue))
+				redacted = true
+			}
+		}
+	}
+	return redacted
+}
+
+// matchesAny reports whether s matches any of patterns.
+func matchesAny(patterns []*regexp.Regexp, s string) bool {
+	for _, pattern := range patterns {
+		if pattern.MatchString(s) {
+			return true
+		}
+	}
+	return false
+}
+
+// maxPayloadSampleLen is the maximum length of a payload sample in bytes.
+const maxPayloadSampleLen = 256
+
+// payloadSample renders payload the same way as bodyAttr and truncates the
+// result to a short single string.
+func (i *LoggingInterceptor) payloadSample(payload any) string {
+	sample := i.bodyAttr("sample", payload).Value.Resolve().String()
+	if len(sample) > maxPayloadSampleLen {
+		sample = truncateString(sample, maxPayloadSampleLen)
+	}
+	return sample
+}
+
+// bodyShape returns the names of the populated top-level fields of a proto
+// message or JSON object without their values.
+func bodyShape(payload any) ([]string, bool) {
+	switch v := payload.(type) {
+	case proto.Message:
+		msg := v.ProtoReflect()
+		if !msg.IsValid() {
+			return []string{}, true
+		}
+
+		fields := msg.Descriptor().Fields()
+		names := make([]string, 0, fields.Len())
+		for idx := range fields.Len() {
+			if fd := fields.Get(idx); msg.Has(fd) {
+				names = append(names, string(fd.Name()))
+			}
+		}
+		return names, true
+	case json.RawMessage:
+		return jsonShape(v)
+	case []byte:
+		return jsonShape(v)
+	case string:
+		return jsonShape([]byte(v))
+	default:
+		return nil, false
+	}
+}
+
+// jsonShape returns the sorted keys of a JSON object.
+func jsonShape(data []byte) ([]string, bool) {
+	var obj map[string]json.RawMessage
+	if err := json.Unmarshal(data, &obj); err != nil || obj == nil {
+		return nil, false
+	}
+
+	names := make([]string, 0, len(obj))
+	for name := range obj {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names, true
+}
+
+// messageTypeName returns the fully-qualified proto name of payload, or its
+// Go type name for non-proto payloads.
+func messageTypeName(payload any) string {
+	if msg, ok := payload.(proto.Message); ok {
+		return string(proto.MessageName(msg))
+	}
+	return reflect.TypeOf(payload).String()
+}
+
+// streamMessageTypes returns the request and response type names declared
+// by the method schema of a protobuf stream.
+func streamMessageTypes(schema any) (string, string, bool) {
+	method, ok := schema.(protoreflect.MethodDescriptor)
+	if !ok {
+		return "", "", false
+	}
+	return string(method.Input().FullName()), string(method.Output().FullName()), true
+}
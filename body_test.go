@@ -0,0 +1,436 @@
+package connectlog
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"reflect"
+	"regexp"
+	"strings"
+	"testing"
+
+	"connectrpc.com/connect"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/dynamicpb"
+	"google.golang.org/protobuf/types/known/typepb"
+)
+
+func TestBodyShape(t *testing.T) {
+	tests := []struct {
+		name     string
+		payload  any
+		expected []string
+		ok       bool
+	}{
+		{
+			name:     "proto message",
+			payload:  &typepb.Field{Name: "email", Number: 3},
+			expected: []string{"number", "name"},
+			ok:       true,
+		},
+		{
+			name:     "empty proto message",
+			payload:  &typepb.Field{},
+			expected: []string{},
+			ok:       true,
+		},
+		{
+			name:     "json object",
+			payload:  []byte(`{"password":"secret","email":"a@b.c"}`),
+			expected: []string{"email", "password"},
+			ok:       true,
+		},
+		{
+			name:    "plain string",
+			payload: "not json",
+		},
+		{
+			name:    "unsupported type",
+			payload: 42,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fields, ok := bodyShape(tt.payload)
+			if ok != tt.ok {
+				t.Fatalf("expected ok %v, got %v", tt.ok, ok)
+			}
+			if !reflect.DeepEqual(fields, tt.expected) {
+				t.Errorf("expected fields %v, got %v", tt.expected, fields)
+			}
+		})
+	}
+}
+
+func TestWithLogBodyShape(t *testing.T) {
+	logger, buf := newTestLogger(slog.LevelDebug)
+	interceptor := NewLoggingInterceptor(WithLogger(logger), WithLogBodyShape(true))
+
+	req := newTestRequest(testProcedure, &typepb.Field{Name: "email", Number: 3})
+	_, err := callUnary(t, interceptor, req, func(context.Context, connect.AnyRequest) (connect.AnyResponse, error) {
+		return connect.NewResponse(&typepb.Field{Name: "ok"}), nil
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	record := findRecord(t, logRecords(t, buf), "request started")
+	if _, ok := record["request"]; ok {
+		t.Error("request body values should not be logged")
+	}
+	if got := record["request_fields"]; !reflect.DeepEqual(got, []any{"number", "name"}) {
+		t.Errorf("expected request_fields [number name], got %v", got)
+	}
+}
+
+type loggableUser struct {
+	ID       string
+	Password string
+}
+
+func (u *loggableUser) LogValue() slog.Value {
+	return slog.GroupValue(slog.String("id", u.ID))
+}
+
+func TestBodyAttr_LogValuer(t *testing.T) {
+	for _, shape := range []bool{false, true} {
+		logger, buf := newTestLogger(slog.LevelDebug)
+		interceptor := NewLoggingInterceptor(WithLogger(logger), WithLogBodyShape(shape))
+
+		req := newTestRequest(testProcedure, &loggableUser{ID: "42", Password: "secret"})
+		_, _ = callUnary(t, interceptor, req, func(context.Context, connect.AnyRequest) (connect.AnyResponse, error) {
+			return connect.NewResponse(&struct{}{}), nil
+		})
+
+		record := findRecord(t, logRecords(t, buf), "request started")
+		expected := map[string]any{"id": "42"}
+		if got := record["request"]; !reflect.DeepEqual(got, expected) {
+			t.Errorf("shape=%v: expected custom rendering %v, got %v", shape, expected, got)
+		}
+	}
+}
+
+// loggableField is a proto message rendering all its values itself.
+type loggableField struct {
+	*typepb.Field
+}
+
+func (f loggableField) LogValue() slog.Value {
+	return slog.GroupValue(slog.String("name", f.Name))
+}
+
+func TestBodyAttr_LogValuerPrivacy(t *testing.T) {
+	tests := []struct {
+		name   string
+		option Option
+	}{
+		{name: "shape", option: WithLogBodyShape(true)},
+		{name: "redacted values", option: WithRedactBodyValuePatterns([]*regexp.Regexp{regexp.MustCompile("secret")})},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logger, buf := newTestLogger(slog.LevelDebug)
+			interceptor := NewLoggingInterceptor(WithLogger(logger), tt.option)
+
+			req := newTestRequest(testProcedure, &loggableField{&typepb.Field{Name: "secret"}})
+			_, _ = callUnary(t, interceptor, req, func(context.Context, connect.AnyRequest) (connect.AnyResponse, error) {
+				return connect.NewResponse(&struct{}{}), nil
+			})
+
+			if strings.Contains(buf.String(), "secret") {
+				t.Errorf("expected the LogValue rendering to be bypassed, got %s", buf)
+			}
+		})
+	}
+}
+
+func TestWithLogMessageTypes(t *testing.T) {
+	logger, buf := newTestLogger(slog.LevelInfo)
+	interceptor := NewLoggingInterceptor(WithLogger(logger), WithLogMessageTypes(true))
+
+	req := newTestRequest(testProcedure, &typepb.Field{Name: "id"})
+	_, _ = callUnary(t, interceptor, req, func(context.Context, connect.AnyRequest) (connect.AnyResponse, error) {
+		return connect.NewResponse(&loggableUser{}), nil
+	})
+
+	record := findRecord(t, logRecords(t, buf), "request completed")
+	if got := record["request_type"]; got != "google.protobuf.Field" {
+		t.Errorf("expected request_type google.protobuf.Field, got %v", got)
+	}
+	if got := record["response_type"]; got != "*connectlog.loggableUser" {
+		t.Errorf("expected Go type name for response_type, got %v", got)
+	}
+}
+
+type blobResponse struct {
+	Name string `json:"name"`
+	Blob []byte `json:"blob,omitempty"`
+}
+
+func TestWithResponseTransformer(t *testing.T) {
+	logger, buf := newTestLogger(slog.LevelDebug)
+	interceptor := NewLoggingInterceptor(WithLogger(logger), WithResponseTransformer(func(msg any) any {
+		if res, ok := msg.(*blobResponse); ok {
+			trimmed := *res
+			trimmed.Blob = nil
+			return &trimmed
+		}
+		return msg
+	}))
+
+	blob := []byte("large embedded blob")
+	res, err := callUnary(t, interceptor, newTestRequest(testProcedure, &struct{}{}), func(context.Context, connect.AnyRequest) (connect.AnyResponse, error) {
+		return connect.NewResponse(&blobResponse{Name: "report", Blob: blob}), nil
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if got := res.Any().(*blobResponse); string(got.Blob) != string(blob) {
+		t.Errorf("expected real response to keep its blob, got %q", got.Blob)
+	}
+	record := findRecord(t, logRecords(t, buf), "response completed")
+	expected := map[string]any{"name": "report"}
+	if got := record["response"]; !reflect.DeepEqual(got, expected) {
+		t.Errorf("expected transformed response %v, got %v", expected, got)
+	}
+}
+
+// wideMessage returns a dynamic proto message with n populated int32
+// fields named f1..fn.
+func wideMessage(t *testing.T, n int) proto.Message {
+	t.Helper()
+
+	fields := make([]*descriptorpb.FieldDescriptorProto, 0, n)
+	for idx := 1; idx <= n; idx++ {
+		name := fmt.Sprintf("f%d", idx)
+		fields = append(fields, &descriptorpb.FieldDescriptorProto{
+			Name:     proto.String(name),
+			JsonName: proto.String(name),
+			Number:   proto.Int32(int32(idx)),
+			Type:     descriptorpb.FieldDescriptorProto_TYPE_INT32.Enum(),
+			Label:    descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL.Enum(),
+		})
+	}
+	file, err := protodesc.NewFile(&descriptorpb.FileDescriptorProto{
+		Name:        proto.String("test/wide.proto"),
+		Package:     proto.String("test.wide"),
+		Syntax:      proto.String("proto3"),
+		MessageType: []*descriptorpb.DescriptorProto{{Name: proto.String("Wide"), Field: fields}},
+	}, nil)
+	if err != nil {
+		t.Fatalf("build descriptor: %v", err)
+	}
+
+	desc := file.Messages().ByName("Wide")
+	msg := dynamicpb.NewMessage(desc)
+	for idx := range desc.Fields().Len() {
+		msg.Set(desc.Fields().Get(idx), protoreflect.ValueOfInt32(int32(idx+1)))
+	}
+	return msg
+}
+
+func TestWithMaxBodyFields(t *testing.T) {
+	msg := wideMessage(t, 50)
+
+	t.Run("values", func(t *testing.T) {
+		interceptor := NewLoggingInterceptor(WithMaxBodyFields(10))
+		attr := interceptor.bodyAttr("request", msg)
+
+		group := attr.Value.Group()
+		if len(group) != 11 {
+			t.Fatalf("expected 10 fields and a marker, got %d attributes", len(group))
+		}
+		if group[0].Key != "f1" || group[9].Key != "f10" {
+			t.Errorf("expected fields f1..f10, got %s..%s", group[0].Key, group[9].Key)
+		}
+		if last := group[10]; last.Key != "_more" || last.Value.Int64() != 40 {
+			t.Errorf("expected _more=40, got %s=%v", last.Key, last.Value)
+		}
+	})
+
+	t.Run("shape", func(t *testing.T) {
+		interceptor := NewLoggingInterceptor(WithMaxBodyFields(10), WithLogBodyShape(true))
+		fields, _ := interceptor.bodyAttr("request", msg).Value.Any().([]string)
+		if len(fields) != 11 || fields[10] != "_more: 40" {
+			t.Errorf("expected 10 names and a _more marker, got %v", fields)
+		}
+	})
+
+	t.Run("under limit", func(t *testing.T) {
+		interceptor := NewLoggingInterceptor(WithMaxBodyFields(100))
+		if attr := interceptor.bodyAttr("request", msg); attr.Value.Kind() == slog.KindGroup {
+			t.Error("expected messages within the limit to be logged unchanged")
+		}
+	})
+}
+
+func TestWithBodyAsJSON(t *testing.T) {
+	tests := []struct {
+		name     string
+		payload  any
+		expected any
+	}{
+		{
+			name:     "proto",
+			payload:  &typepb.Type{Name: "acme.User", Oneofs: []string{"contact"}},
+			expected: map[string]any{"name": "acme.User", "oneofs": []any{"contact"}},
+		},
+		{
+			name:     "struct",
+			payload:  &blobResponse{Name: "report"},
+			expected: map[string]any{"name": "report"},
+		},
+		{
+			name:     "json string",
+			payload:  `{"id":42}`,
+			expected: map[string]any{"id": float64(42)},
+		},
+		{
+			name:     "plain string",
+			payload:  "not json",
+			expected: map[string]any{"raw": "not json"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logger, buf := newTestLogger(slog.LevelDebug)
+			interceptor := NewLoggingInterceptor(WithLogger(logger), WithBodyAsJSON(true))
+			logger.Debug("body", interceptor.bodyAttr("request", tt.payload))
+
+			if got := logRecords(t, buf)[0]["request"]; !reflect.DeepEqual(got, tt.expected) {
+				t.Errorf("expected %v, got %v", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestWithBodyLoggingCodes(t *testing.T) {
+	logger, buf := newTestLogger(slog.LevelDebug)
+	interceptor := NewLoggingInterceptor(WithLogger(logger), WithBodyLoggingCodes(connect.CodeInvalidArgument))
+
+	for _, code := range []connect.Code{0, connect.CodeNotFound, connect.CodeInvalidArgument} {
+		req := newTestRequest(testProcedure, &blobResponse{Name: code.String()})
+		_, _ = callUnary(t, interceptor, req, func(context.Context, connect.AnyRequest) (connect.AnyResponse, error) {
+			if code != 0 {
+				return nil, connect.NewError(code, nil)
+			}
+			return connect.NewResponse(&blobResponse{Name: "result"}), nil
+		})
+	}
+
+	var bodies []any
+	for _, record := range logRecords(t, buf) {
+		if record[slog.MessageKey] == "request started" {
+			if _, ok := record["request"]; ok {
+				t.Error("expected no request body in the start log")
+			}
+			continue
+		}
+		if body, ok := record["request"]; ok {
+			bodies = append(bodies, body)
+		}
+		if _, ok := record["response"]; ok {
+			t.Errorf("expected no response body for successful calls, got %v", record)
+		}
+	}
+
+	expected := []any{map[string]any{"name": "invalid_argument"}}
+	if !reflect.DeepEqual(bodies, expected) {
+		t.Errorf("expected only the invalid_argument body, got %v", bodies)
+	}
+}
+
+func TestWithBodyAsYAML(t *testing.T) {
+	logger, buf := newTestLogger(slog.LevelDebug)
+	interceptor := NewLoggingInterceptor(WithLogger(logger), WithBodyAsYAML(true))
+
+	payload := &typepb.Type{
+		Name:   "acme.User",
+		Fields: []*typepb.Field{{Name: "id", Number: 1}},
+		Oneofs: []string{"contact"},
+	}
+	logger.Debug("body", interceptor.bodyAttr("request", payload))
+
+	expected := "name: acme.User\n" +
+		"fields:\n" +
+		"    - number: 1\n" +
+		"      name: id\n" +
+		"oneofs:\n" +
+		"    - contact\n"
+	if got := logRecords(t, buf)[0]["request"]; got != expected {
+		t.Errorf("expected %q, got %q", expected, got)
+	}
+}
+
+func TestWithLogCodecSizes(t *testing.T) {
+	logger, buf := newTestLogger(slog.LevelDebug)
+	interceptor := NewLoggingInterceptor(WithLogger(logger), WithLogCodecSizes(true))
+
+	msg := &typepb.Type{Name: "acme.User", Oneofs: []string{"contact"}}
+	req := newTestRequest(testProcedure, msg)
+	_, _ = callUnary(t, interceptor, req, func(context.Context, connect.AnyRequest) (connect.AnyResponse, error) {
+		return connect.NewResponse(&blobResponse{Name: "result"}), nil
+	})
+
+	records := logRecords(t, buf)
+	data, _ := protojson.Marshal(msg)
+	expected := map[string]any{"proto_size": float64(proto.Size(msg)), "json_size": float64(len(data))}
+	if got := findRecord(t, records, "request started")["request_sizes"]; !reflect.DeepEqual(got, expected) {
+		t.Errorf("expected request sizes %v, got %v", expected, got)
+	}
+	if got, ok := findRecord(t, records, "response completed")["response_sizes"]; ok {
+		t.Errorf("expected no sizes for a non-proto response, got %v", got)
+	}
+}
+
+// countingBody counts how often it is marshaled to JSON.
+type countingBody struct {
+	marshaled *int
+}
+
+func (b countingBody) MarshalJSON() ([]byte, error) {
+	*b.marshaled++
+	return []byte(`{"name":"counted"}`), nil
+}
+
+// discardHandler accepts every record without reading its attributes.
+type discardHandler struct{}
+
+func (discardHandler) Enabled(context.Context, slog.Level) bool  { return true }
+func (discardHandler) Handle(context.Context, slog.Record) error { return nil }
+func (h discardHandler) WithAttrs([]slog.Attr) slog.Handler      { return h }
+func (h discardHandler) WithGroup(string) slog.Handler           { return h }
+
+func TestWithLazyBodies(t *testing.T) {
+	tests := []struct {
+		name     string
+		lazy     bool
+		handler  slog.Handler
+		expected int
+	}{
+		{name: "eager dropped", handler: discardHandler{}, expected: 1},
+		{name: "lazy dropped", lazy: true, handler: discardHandler{}, expected: 0},
+		{name: "lazy emitted", lazy: true, handler: slog.NewJSONHandler(io.Discard, nil), expected: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			interceptor := NewLoggingInterceptor(WithBodyAsJSON(true), WithLazyBodies(tt.lazy))
+
+			var marshaled int
+			slog.New(tt.handler).Info("body", interceptor.bodyAttr("request", countingBody{&marshaled}))
+			if marshaled != tt.expected {
+				t.Errorf("expected %d marshal calls, got %d", tt.expected, marshaled)
+			}
+		})
+	}
+}
@@ -0,0 +1,52 @@
+package connectlog
+
+import (
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"strconv"
+	"strings"
+)
+
+// packagePrefix is the function name prefix of this package's frames.
+var packagePrefix = reflect.TypeFor[LoggingInterceptor]().PkgPath() + "."
+
+// maxCallerDepth bounds the number of frames inspected by callerInfo.
+const maxCallerDepth = 32
+
+// callerInfo returns "file:line" of the innermost stack frame belonging to
+// user code, skipping frames of the runtime, Connect, generated Connect
+// stubs and this interceptor (except its tests). It returns "" when no such
+// frame is found, as for server-side unary calls, where the handler is not
+// on the stack yet.
+func callerInfo() string {
+	var pcs [maxCallerDepth]uintptr
+	n := runtime.Callers(2, pcs[:]) // skip runtime.Callers and callerInfo
+	frames := runtime.CallersFrames(pcs[:n])
+	for {
+		frame, more := frames.Next()
+		if !internalFrame(frame) {
+			return filepath.Base(frame.File) + ":" + strconv.Itoa(frame.Line)
+		}
+		if !more {
+			return ""
+		}
+	}
+}
+
+// internalFrame reports whether frame belongs to the runtime, the Connect
+// library, a client stub generated by protoc-gen-connect-go (*.connect.go),
+// the HTTP server or non-test code of this package.
+func internalFrame(frame runtime.Frame) bool {
+	switch {
+	case strings.HasPrefix(frame.Function, "runtime."),
+		strings.HasPrefix(frame.Function, "connectrpc.com/connect."),
+		strings.HasPrefix(frame.Function, "net/http."),
+		strings.HasSuffix(frame.File, ".connect.go"):
+		return true
+	case strings.HasPrefix(frame.Function, packagePrefix):
+		return !strings.HasSuffix(frame.File, "_test.go")
+	default:
+		return false
+	}
+}
@@ -0,0 +1,116 @@
+package connectlog
+
+import (
+	"context"
+	"log/slog"
+	"net/http/httptest"
+	"regexp"
+	"runtime"
+	"testing"
+
+	"connectrpc.com/connect"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+// echoProcedure is the procedure served by the echo handlers of the tests.
+const echoProcedure = "/acme.test.v1.EchoService/Echo"
+
+func TestWithCallerInfo(t *testing.T) {
+	callerPattern := regexp.MustCompile(`^caller_test\.go:\d+$`)
+
+	t.Run("stream", func(t *testing.T) {
+		logger, buf := newTestLogger(slog.LevelInfo)
+		interceptor := NewLoggingInterceptor(WithLogger(logger), WithCallerInfo(true))
+
+		handler := interceptor.WrapStreamingHandler(func(_ context.Context, conn connect.StreamingHandlerConn) error {
+			return conn.Send(wrapperspb.String("hello"))
+		})
+		_ = handler(context.Background(), newTestStreamConn(connect.StreamTypeServer, 0))
+
+		caller, _ := findRecord(t, logRecords(t, buf), "stream completed")["caller"].(string)
+		if !callerPattern.MatchString(caller) {
+			t.Errorf("expected caller in caller_test.go, got %q", caller)
+		}
+	})
+
+	t.Run("client unary", func(t *testing.T) {
+		logger, buf := newTestLogger(slog.LevelInfo)
+		interceptor := NewLoggingInterceptor(WithLogger(logger), WithCallerInfo(true))
+
+		server := httptest.NewServer(connect.NewUnaryHandler(echoProcedure, echo))
+		defer server.Close()
+		client := connect.NewClient[wrapperspb.StringValue, wrapperspb.StringValue](
+			server.Client(), server.URL+echoProcedure, connect.WithInterceptors(interceptor))
+		if _, err := client.CallUnary(context.Background(), connect.NewRequest(wrapperspb.String("hello"))); err != nil {
+			t.Fatal(err)
+		}
+
+		caller, _ := findRecord(t, logRecords(t, buf), "request completed")["caller"].(string)
+		if !callerPattern.MatchString(caller) {
+			t.Errorf("expected caller in caller_test.go, got %q", caller)
+		}
+	})
+
+	t.Run("server unary", func(t *testing.T) {
+		logger, buf := newTestLogger(slog.LevelInfo)
+		interceptor := NewLoggingInterceptor(WithLogger(logger), WithCallerInfo(true))
+
+		server := httptest.NewServer(connect.NewUnaryHandler(echoProcedure, func(ctx context.Context, req *connect.Request[wrapperspb.StringValue]) (*connect.Response[wrapperspb.StringValue], error) {
+			RecordCaller(ctx)
+			return echo(ctx, req)
+		}, connect.WithInterceptors(interceptor)))
+		defer server.Close()
+		client := connect.NewClient[wrapperspb.StringValue, wrapperspb.StringValue](server.Client(), server.URL+echoProcedure)
+		if _, err := client.CallUnary(context.Background(), connect.NewRequest(wrapperspb.String("hello"))); err != nil {
+			t.Fatal(err)
+		}
+
+		caller, _ := findRecord(t, logRecords(t, buf), "request completed")["caller"].(string)
+		if !callerPattern.MatchString(caller) {
+			t.Errorf("expected caller in caller_test.go, got %q", caller)
+		}
+	})
+
+	t.Run("server unary without RecordCaller", func(t *testing.T) {
+		logger, buf := newTestLogger(slog.LevelInfo)
+		interceptor := NewLoggingInterceptor(WithLogger(logger), WithCallerInfo(true))
+
+		_, _ = interceptor.WrapUnary(func(ctx context.Context, _ connect.AnyRequest) (connect.AnyResponse, error) {
+			LoggerFromContext(ctx).InfoContext(ctx, "handling")
+			AddAttr(ctx, slog.String("user_id", "42"))
+			return connect.NewResponse(&struct{}{}), nil
+		})(context.Background(), newTestRequest(testProcedure, &struct{}{}))
+
+		if caller, ok := findRecord(t, logRecords(t, buf), "request completed")["caller"]; ok {
+			t.Errorf("expected no caller for handlers not calling RecordCaller, got %v", caller)
+		}
+	})
+}
+
+func TestInternalFrame(t *testing.T) {
+	tests := []struct {
+		name     string
+		frame    runtime.Frame
+		expected bool
+	}{
+		{name: "runtime", frame: runtime.Frame{Function: "runtime.goexit", File: "runtime/asm_amd64.s"}, expected: true},
+		{name: "connect", frame: runtime.Frame{Function: "connectrpc.com/connect.(*Client[...]).CallUnary", File: "client.go"}, expected: true},
+		{name: "generated stub", frame: runtime.Frame{Function: "acme/pingv1connect.(*pingServiceClient).Ping", File: "ping.connect.go"}, expected: true},
+		{name: "interceptor", frame: runtime.Frame{Function: packagePrefix + "(*LoggingInterceptor).WrapUnary.func1", File: "interceptor.go"}, expected: true},
+		{name: "interceptor tests", frame: runtime.Frame{Function: packagePrefix + "TestInternalFrame", File: "caller_test.go"}},
+		{name: "user code", frame: runtime.Frame{Function: "acme/server.(*PingServer).Ping", File: "server.go"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := internalFrame(tt.frame); got != tt.expected {
+				t.Errorf("expected %v, got %v", tt.expected, got)
+			}
+		})
+	}
+}
+
+// echo is a handler of echoProcedure returning the request.
+func echo(_ context.Context, req *connect.Request[wrapperspb.StringValue]) (*connect.Response[wrapperspb.StringValue], error) {
+	return connect.NewResponse(req.Msg), nil
+}
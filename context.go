@@ -0,0 +1,142 @@
+package connectlog
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"log/slog"
+	"slices"
+	"sync"
+)
+
+// loggerKey is the context key for the request-scoped logger.
+type loggerKey struct{}
+
+// contextWithLogger returns a copy of ctx carrying the request logger.
+func contextWithLogger(ctx context.Context, logger *slog.Logger) context.Context {
+	return context.WithValue(ctx, loggerKey{}, logger)
+}
+
+// LoggerFromContext returns the request-scoped logger built by the
+// interceptor, already carrying the service, method and peer attributes.
+// It returns slog.Default() if ctx doesn't come from an intercepted call.
+func LoggerFromContext(ctx context.Context) *slog.Logger {
+	if logger, ok := ctx.Value(loggerKey{}).(*slog.Logger); ok {
+		return logger
+	}
+	return slog.Default()
+}
+
+// attrsKey is the context key for the completion attributes collector.
+type attrsKey struct{}
+
+// attrCollector gathers attributes added by handlers with AddAttr. It is
+// safe for concurrent use, as handlers may add attributes from goroutines.
+type attrCollector struct {
+	mu     sync.Mutex
+	attrs  []slog.Attr
+	closed bool
+
+	// wantCaller makes the collector record the caller of the first
+	// RecordCaller call, as the handler of a server-side unary call is not
+	// on the interceptor's stack.
+	wantCaller bool
+	caller     string
+}
+
+// contextWithCollector returns a copy of ctx carrying a new collector.
+func contextWithCollector(ctx context.Context) (context.Context, *attrCollector) {
+	collector := new(attrCollector)
+	return context.WithValue(ctx, attrsKey{}, collector), collector
+}
+
+// drain closes the collector and returns the collected attributes in the
+// order they were added as arguments for a log call. Attributes added
+// afterwards are ignored.
+func (c *attrCollector) drain() []any {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.closed = true
+	args := make([]any, len(c.attrs))
+	for idx, attr := range c.attrs {
+		args[idx] = attr
+	}
+	c.attrs = nil
+	return args
+}
+
+// AddAttr adds attributes to the completion log of the call carried by
+// ctx, e.g. a user id resolved by the handler. Attributes are logged in the
+// order they were added; it is safe to call from multiple goroutines, but
+// the order of attributes added concurrently is unspecified. Attributes
+// added after the call completed, or with a
+// context that doesn't come from an intercepted call, are ignored.
+func AddAttr(ctx context.Context, attrs ...slog.Attr) {
+	collector, ok := ctx.Value(attrsKey{}).(*attrCollector)
+	if !ok {
+		return
+	}
+
+	collector.mu.Lock()
+	defer collector.mu.Unlock()
+	if !collector.closed {
+		collector.attrs = append(collector.attrs, attrs...)
+	}
+}
+
+// RecordCaller records the code calling it as the caller logged by
+// WithCallerInfo for the server-side unary call carried by ctx, whose
+// handler is not on the interceptor's stack. Only the first call is
+// recorded. It does nothing without WithCallerInfo or with a context that
+// doesn't come from an intercepted server-side unary call.
+func RecordCaller(ctx context.Context) {
+	collector, ok := ctx.Value(attrsKey{}).(*attrCollector)
+	if !ok {
+		return
+	}
+
+	collector.mu.Lock()
+	defer collector.mu.Unlock()
+	if collector.wantCaller && !collector.closed && collector.caller == "" {
+		collector.caller = callerInfo()
+	}
+}
+
+// handlerCaller returns the handler code recorded by RecordCaller.
+func (c *attrCollector) handlerCaller() string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.caller
+}
+
+// contextValue is a context key logged under an attribute name by
+// WithContextValues.
+type contextValue struct {
+	key  any
+	name string
+}
+
+// newContextValues returns the keys sorted by attribute name, so that the
+// attributes are logged in a stable order.
+func newContextValues(keys map[any]string) []contextValue {
+	values := make([]contextValue, 0, len(keys))
+	for key, name := range keys {
+		values = append(values, contextValue{key: key, name: name})
+	}
+	slices.SortFunc(values, func(a, b contextValue) int {
+		return cmp.Compare(a.name, b.name)
+	})
+	return values
+}
+
+// appendContextValues appends the configured context values present in
+// ctx, formatted with fmt.
+func appendContextValues(ctx context.Context, attrs []slog.Attr, values []contextValue) []slog.Attr {
+	for _, v := range values {
+		if value := ctx.Value(v.key); value != nil {
+			attrs = append(attrs, slog.String(v.name, fmt.Sprint(value)))
+		}
+	}
+	return attrs
+}
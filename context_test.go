@@ -0,0 +1,190 @@
+package connectlog
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"slices"
+	"sync"
+	"testing"
+
+	"connectrpc.com/connect"
+)
+
+func TestLoggerFromContext(t *testing.T) {
+	logger, buf := newTestLogger(slog.LevelInfo)
+	interceptor := NewLoggingInterceptor(WithLogger(logger))
+
+	req := newTestRequest(testProcedure, &struct{}{})
+	_, _ = callUnary(t, interceptor, req, func(ctx context.Context, _ connect.AnyRequest) (connect.AnyResponse, error) {
+		LoggerFromContext(ctx).InfoContext(ctx, "handler log")
+		return connect.NewResponse(&struct{}{}), nil
+	})
+
+	stream := interceptor.WrapStreamingHandler(func(ctx context.Context, _ connect.StreamingHandlerConn) error {
+		LoggerFromContext(ctx).InfoContext(ctx, "stream handler log")
+		return nil
+	})
+	_ = stream(context.Background(), newTestStreamConn(connect.StreamTypeServer, 0))
+
+	records := logRecords(t, buf)
+	for _, msg := range []string{"handler log", "stream handler log"} {
+		record := findRecord(t, records, msg)
+		if got := record["service"]; got != "acme.test.v1.TestService" {
+			t.Errorf("%s: expected service attribute, got %v", msg, got)
+		}
+	}
+}
+
+func TestLoggerFromContext_Default(t *testing.T) {
+	if got := LoggerFromContext(context.Background()); got != slog.Default() {
+		t.Error("expected slog.Default() without an intercepted context")
+	}
+}
+
+func TestAddAttr(t *testing.T) {
+	logger, buf := newTestLogger(slog.LevelInfo)
+	interceptor := NewLoggingInterceptor(WithLogger(logger))
+
+	const workers = 8
+	var handlerCtx context.Context
+	req := newTestRequest(testProcedure, &struct{}{})
+	_, _ = callUnary(t, interceptor, req, func(ctx context.Context, _ connect.AnyRequest) (connect.AnyResponse, error) {
+		handlerCtx = ctx
+		var wg sync.WaitGroup
+		for n := range workers {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				AddAttr(ctx, slog.Int(fmt.Sprintf("worker_%d", n), n))
+			}()
+		}
+		wg.Wait()
+		return connect.NewResponse(&struct{}{}), nil
+	})
+
+	// Late additions are ignored
+	AddAttr(handlerCtx, slog.String("late", "value"))
+	AddAttr(context.Background(), slog.String("orphan", "value"))
+
+	record := findRecord(t, logRecords(t, buf), "request completed")
+	for n := range workers {
+		if got := record[fmt.Sprintf("worker_%d", n)]; got != float64(n) {
+			t.Errorf("expected worker_%d attribute, got %v", n, got)
+		}
+	}
+	if _, ok := record["late"]; ok {
+		t.Error("expected attributes added after completion to be ignored")
+	}
+}
+
+func TestAddAttr_Stream(t *testing.T) {
+	logger, buf := newTestLogger(slog.LevelInfo)
+	interceptor := NewLoggingInterceptor(WithLogger(logger))
+
+	stream := interceptor.WrapStreamingHandler(func(ctx context.Context, _ connect.StreamingHandlerConn) error {
+		AddAttr(ctx, slog.String("user_id", "42"))
+		return nil
+	})
+	_ = stream(context.Background(), newTestStreamConn(connect.StreamTypeServer, 0))
+
+	if got := findRecord(t, logRecords(t, buf), "stream completed")["user_id"]; got != "42" {
+		t.Errorf("expected user_id 42, got %v", got)
+	}
+}
+
+type (
+	userIDKey  struct{}
+	regionKey  struct{}
+	missingKey struct{}
+)
+
+func TestWithContextValues(t *testing.T) {
+	logger, buf := newTestLogger(slog.LevelInfo)
+	interceptor := NewLoggingInterceptor(WithLogger(logger), WithContextValues(map[any]string{
+		userIDKey{}:  "user_id",
+		regionKey{}:  "region",
+		missingKey{}: "missing",
+	}))
+
+	ctx := context.WithValue(context.Background(), userIDKey{}, 42)
+	ctx = context.WithValue(ctx, regionKey{}, "eu-west-1")
+	handler := interceptor.WrapUnary(func(context.Context, connect.AnyRequest) (connect.AnyResponse, error) {
+		return connect.NewResponse(&struct{}{}), nil
+	})
+	if _, err := handler(ctx, newTestRequest(testProcedure, &struct{}{})); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	record := findRecord(t, logRecords(t, buf), "request completed")
+	if record["user_id"] != "42" || record["region"] != "eu-west-1" {
+		t.Errorf("expected user_id 42 and region eu-west-1, got %v", record)
+	}
+	if got, ok := record["missing"]; ok {
+		t.Errorf("expected missing keys to be skipped, got %v", got)
+	}
+}
+
+// recordKeys returns the top-level keys of a JSON log line in order.
+func recordKeys(t *testing.T, line []byte) []string {
+	t.Helper()
+
+	dec := json.NewDecoder(bytes.NewReader(line))
+	if _, err := dec.Token(); err != nil { // opening brace
+		t.Fatalf("decode record: %v", err)
+	}
+	var keys []string
+	for dec.More() {
+		key, err := dec.Token()
+		if err != nil {
+			t.Fatalf("decode record: %v", err)
+		}
+		keys = append(keys, key.(string))
+		var value json.RawMessage
+		if err := dec.Decode(&value); err != nil {
+			t.Fatalf("decode record: %v", err)
+		}
+	}
+	return keys
+}
+
+func TestAttributeOrder(t *testing.T) {
+	run := func() []string {
+		logger, buf := newTestLogger(slog.LevelInfo)
+		interceptor := NewLoggingInterceptor(
+			WithLogger(logger),
+			WithEnvironment("test"),
+			WithSequenceNumbers(true),
+			WithContextValues(map[any]string{userIDKey{}: "user_id", regionKey{}: "region"}),
+			WithAttrsFunc(func() []slog.Attr { return []slog.Attr{slog.String("host", "node-1")} }),
+		)
+
+		ctx := context.WithValue(context.Background(), userIDKey{}, 42)
+		ctx = context.WithValue(ctx, regionKey{}, "eu")
+		handler := interceptor.WrapUnary(func(ctx context.Context, _ connect.AnyRequest) (connect.AnyResponse, error) {
+			for _, key := range []string{"c", "a", "b"} {
+				AddAttr(ctx, slog.String(key, key))
+			}
+			return connect.NewResponse(&struct{}{}), nil
+		})
+		if _, err := handler(ctx, newTestRequest(testProcedure, &struct{}{})); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		return recordKeys(t, bytes.TrimSpace(buf.Bytes()))
+	}
+
+	expected := []string{
+		"time", "level", "msg",
+		"service", "method", "protocol", "addr", "env", "host", "region", "user_id",
+		"duration", "seq", "c", "a", "b",
+	}
+	for range 20 {
+		got := run()
+		// Result attributes (code, sizes, ...) follow in a fixed order
+		if len(got) < len(expected) || !slices.Equal(got[:len(expected)], expected) {
+			t.Fatalf("expected keys to start with %v, got %v", expected, got)
+		}
+	}
+}
@@ -3,17 +3,45 @@ package connectlog
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
+	"io"
 	"log/slog"
 	"os"
+	"strings"
+	"time"
 
 	"connectrpc.com/connect"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
 )
 
 // loggableError wraps a connect.Error and implements slog.LogValuer
 // for structured logging of errors with Connect codes and details.
 type loggableError struct {
 	*connect.Error
+	generic bool // log the canonical message of the code
+}
+
+// ErrorInfo describes a failed call passed to the WithOnError callback.
+type ErrorInfo struct {
+	Code      connect.Code // resolved Connect code
+	Message   string       // error message without the code prefix
+	Procedure string       // for example, "/acme.foo.v1.FooService/Bar"
+	Err       error        // original error returned by the handler
+}
+
+// codeOK is the code logged for successful calls; connect.Code has no
+// name for success.
+const codeOK = "ok"
+
+// resultCode returns the code of a completed call: 0 for success, including
+// streams that ended with io.EOF.
+func resultCode(err error) connect.Code {
+	if err == nil || errors.Is(err, io.EOF) {
+		return 0
+	}
+	return newLoggableError(err).Code()
 }
 
 // Predefined errors for common context cases to avoid allocations
@@ -26,14 +54,39 @@ var (
 	}
 )
 
+// connectCoder is implemented by domain errors mapping themselves to a
+// Connect code.
+type connectCoder interface {
+	ConnectCode() connect.Code
+}
+
 // newLoggableError creates a LoggableError from any error value.
 // It preserves connect.Error values, handles context errors specially,
+// uses the non-zero code of errors implementing ConnectCode() connect.Code,
 // and wraps all other errors as Unknown.
+//
+// For joined errors (errors.Join or fmt.Errorf with several %w verbs) each
+// error is classified separately and the most severe one is returned:
+// server-side codes (Internal and above) win over client-side ones, and
+// among equally severe errors the first one wins.
 func newLoggableError(err error) *loggableError {
 	if err == nil {
 		return nil
 	}
 
+	if joined, ok := err.(interface{ Unwrap() []error }); ok {
+		var worst *loggableError
+		for _, e := range joined.Unwrap() {
+			le := newLoggableError(e)
+			if le != nil && (worst == nil || codeSeverity(le.Code()) > codeSeverity(worst.Code())) {
+				worst = le
+			}
+		}
+		if worst != nil {
+			return worst
+		}
+	}
+
 	// Preserve existing connect.Error values
 	var connectErr *connect.Error
 	if errors.As(err, &connectErr) {
@@ -48,23 +101,85 @@ func newLoggableError(err error) *loggableError {
 		return errDeadline
 	}
 
-	// Wrap all other errors as Unknown
+	// Domain errors may classify themselves; all other errors, and those
+	// without a code (0 is not an error code), are Unknown
+	code := connect.CodeUnknown
+	var coder connectCoder
+	if errors.As(err, &coder) && coder.ConnectCode() != 0 {
+		code = coder.ConnectCode()
+	}
 	return &loggableError{
-		Error: connect.NewError(connect.CodeUnknown, err),
+		Error: connect.NewError(code, err),
+	}
+}
+
+// cancelSource reports whether a canceled request was ended by its own
+// deadline ("deadline") or by the client going away ("client").
+func cancelSource(ctx context.Context) string {
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return "deadline"
+	}
+	if deadline, ok := ctx.Deadline(); ok && !time.Now().Before(deadline) {
+		return "deadline"
+	}
+	return "client"
+}
+
+// errorClass groups error codes for alerting: "client" for errors caused
+// by the request (4xx-equivalent), "transient" for errors worth retrying
+// and "server" for everything else.
+func errorClass(code connect.Code) string {
+	switch code {
+	case connect.CodeCanceled,
+		connect.CodeInvalidArgument,
+		connect.CodeNotFound,
+		connect.CodeAlreadyExists,
+		connect.CodePermissionDenied,
+		connect.CodeFailedPrecondition,
+		connect.CodeOutOfRange,
+		connect.CodeUnimplemented,
+		connect.CodeUnauthenticated:
+		return "client"
+	case connect.CodeDeadlineExceeded,
+		connect.CodeResourceExhausted,
+		connect.CodeAborted,
+		connect.CodeUnavailable:
+		return "transient"
+	default:
+		return "server"
 	}
 }
 
+// genericMessage returns the canonical message of code, e.g. "not found".
+func genericMessage(code connect.Code) string {
+	return strings.ReplaceAll(code.String(), "_", " ")
+}
+
+// codeSeverity ranks error codes the same way the interceptor picks log
+// levels: codes logged at Error outrank codes logged at Warn.
+func codeSeverity(code connect.Code) int {
+	if code < connect.CodeInternal {
+		return 0
+	}
+	return 1
+}
+
 // LogValue implements slog.LogValuer, providing structured error details.
-// It always includes the error code and message, and adds any additional
-// details from the original error if it implements slog.LogValuer.
+// It always includes the error code and message, adds field violations of
+// validation errors (see validationViolations), and any additional details
+// from the original error if it implements slog.LogValuer.
 func (e *loggableError) LogValue() slog.Value {
 	if e == nil {
 		return slog.Value{}
 	}
 
+	message := e.Message()
+	if e.generic {
+		message = genericMessage(e.Code())
+	}
 	attrs := []slog.Attr{
 		slog.String("code", e.Code().String()),
-		slog.String("message", e.Message()),
+		slog.String("message", message),
 	}
 
 	// Extract details from the original error (bypassing connect.Error wrapper)
@@ -73,6 +188,10 @@ func (e *loggableError) LogValue() slog.Value {
 		origErr = e.Error
 	}
 
+	if violations, ok := validationViolations(origErr); ok {
+		attrs = append(attrs, slog.Any("violations", violations))
+	}
+
 	var logValuer slog.LogValuer
 	if errors.As(origErr, &logValuer) {
 		logValue := logValuer.LogValue()
@@ -86,3 +205,39 @@ func (e *loggableError) LogValue() slog.Value {
 
 	return slog.GroupValue(attrs...)
 }
+
+// errorDetails decodes the details of a Connect error for logging, with
+// the body redaction applied. Details of unknown types, and details the
+// Redactor doesn't return as proto messages, are represented by their type
+// name only.
+func (i *LoggingInterceptor) errorDetails(connErr *loggableError) []map[string]any {
+	details := make([]map[string]any, 0, len(connErr.Details()))
+	for _, detail := range connErr.Details() {
+		fields := map[string]any{}
+		if msg, err := detail.Value(); err == nil {
+			if msg, ok := i.redactor.RedactBody(msg).(proto.Message); ok {
+				if data, err := protojson.Marshal(msg); err == nil {
+					_ = json.Unmarshal(data, &fields)
+				}
+			}
+		}
+		fields["@type"] = detail.Type()
+		details = append(details, fields)
+	}
+	return details
+}
+
+// notifyError invokes the WithOnError callback for a failed call.
+func (i *LoggingInterceptor) notifyError(ctx context.Context, spec connect.Spec, err error) {
+	if i.options.OnError == nil {
+		return
+	}
+
+	connErr := newLoggableError(err)
+	i.options.OnError(ctx, ErrorInfo{
+		Code:      connErr.Code(),
+		Message:   connErr.Message(),
+		Procedure: spec.Procedure,
+		Err:       err,
+	})
+}
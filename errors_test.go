@@ -4,12 +4,20 @@ package connectlog
 import (
 	"context"
 	"errors"
+	"fmt"
 	"log/slog"
 	"os"
 	"reflect"
+	"strings"
 	"testing"
 
 	"connectrpc.com/connect"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/dynamicpb"
 )
 
 type detailedError struct {
@@ -28,6 +36,28 @@ func (e detailedError) LogValue() slog.Value {
 	)
 }
 
+// domainError maps itself to a Connect code.
+type domainError struct{}
+
+func (domainError) Error() string {
+	return "account is locked"
+}
+
+func (domainError) ConnectCode() connect.Code {
+	return connect.CodeFailedPrecondition
+}
+
+// uncodedError implements ConnectCode without mapping itself to a code.
+type uncodedError struct{}
+
+func (uncodedError) Error() string {
+	return "not classified"
+}
+
+func (uncodedError) ConnectCode() connect.Code {
+	return 0
+}
+
 func TestNewLoggableError(t *testing.T) {
 	tests := []struct {
 		name     string
@@ -107,6 +137,30 @@ func TestNewLoggableError(t *testing.T) {
 				details: true,
 			},
 		},
+		{
+			name:  "domain error with code",
+			input: fmt.Errorf("unlock: %w", domainError{}),
+			expected: struct {
+				code    connect.Code
+				message string
+				details bool
+			}{
+				code:    connect.CodeFailedPrecondition,
+				message: "unlock: account is locked",
+			},
+		},
+		{
+			name:  "domain error without code",
+			input: uncodedError{},
+			expected: struct {
+				code    connect.Code
+				message string
+				details bool
+			}{
+				code:    connect.CodeUnknown,
+				message: "not classified",
+			},
+		},
 		{
 			name: "connect error wrapping context",
 			input: connect.NewError(connect.CodeAborted,
@@ -202,6 +256,80 @@ func TestLoggableError_LogValue(t *testing.T) {
 	})
 }
 
+func TestNewLoggableError_Joined(t *testing.T) {
+	notFound := connect.NewError(connect.CodeNotFound, errors.New("user not found"))
+	internal := connect.NewError(connect.CodeInternal, errors.New("database unavailable"))
+
+	tests := []struct {
+		name    string
+		input   error
+		code    connect.Code
+		message string
+	}{
+		{
+			name:    "internal wins over not found",
+			input:   errors.Join(notFound, internal),
+			code:    connect.CodeInternal,
+			message: "database unavailable",
+		},
+		{
+			name:    "order does not matter",
+			input:   errors.Join(internal, notFound),
+			code:    connect.CodeInternal,
+			message: "database unavailable",
+		},
+		{
+			name:    "first of equal severity wins",
+			input:   errors.Join(notFound, connect.NewError(connect.CodeInvalidArgument, errors.New("bad id"))),
+			code:    connect.CodeNotFound,
+			message: "user not found",
+		},
+		{
+			name:    "context error joined with plain error",
+			input:   errors.Join(context.Canceled, errors.New("cleanup failed")),
+			code:    connect.CodeCanceled,
+			message: "request canceled",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := newLoggableError(tt.input)
+			if got := err.Code(); got != tt.code {
+				t.Errorf("expected code %v, got %v", tt.code, got)
+			}
+			if got := err.Message(); got != tt.message {
+				t.Errorf("expected message %q, got %q", tt.message, got)
+			}
+		})
+	}
+}
+
+func TestErrorClass(t *testing.T) {
+	tests := []struct {
+		code     connect.Code
+		expected string
+	}{
+		{code: connect.CodeInvalidArgument, expected: "client"},
+		{code: connect.CodeNotFound, expected: "client"},
+		{code: connect.CodeUnauthenticated, expected: "client"},
+		{code: connect.CodeInternal, expected: "server"},
+		{code: connect.CodeUnknown, expected: "server"},
+		{code: connect.CodeDataLoss, expected: "server"},
+		{code: connect.CodeUnavailable, expected: "transient"},
+		{code: connect.CodeResourceExhausted, expected: "transient"},
+		{code: connect.CodeAborted, expected: "transient"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.code.String(), func(t *testing.T) {
+			if got := errorClass(tt.code); got != tt.expected {
+				t.Errorf("expected class %q, got %q", tt.expected, got)
+			}
+		})
+	}
+}
+
 func TestErrorReuse(t *testing.T) {
 	// Verify we reuse the same instances for context errors
 	err1 := newLoggableError(context.Canceled)
@@ -216,3 +344,181 @@ func TestErrorReuse(t *testing.T) {
 		t.Error("expected same instance for deadline exceeded errors")
 	}
 }
+
+func TestWithOnError(t *testing.T) {
+	logger, buf := newTestLogger(slog.LevelInfo)
+
+	var infos []ErrorInfo
+	interceptor := NewLoggingInterceptor(WithLogger(logger), WithOnError(func(_ context.Context, info ErrorInfo) {
+		if buf.Len() == 0 {
+			t.Error("expected callback to run after logging")
+		}
+		infos = append(infos, info)
+	}))
+
+	cause := connect.NewError(connect.CodeNotFound, errors.New("user not found"))
+	req := newTestRequest(testProcedure, &struct{}{})
+	_, _ = callUnary(t, interceptor, req, func(context.Context, connect.AnyRequest) (connect.AnyResponse, error) {
+		return nil, cause
+	})
+	_, _ = callUnary(t, interceptor, req, func(context.Context, connect.AnyRequest) (connect.AnyResponse, error) {
+		return connect.NewResponse(&struct{}{}), nil
+	})
+
+	if len(infos) != 1 {
+		t.Fatalf("expected 1 callback invocation, got %d", len(infos))
+	}
+	expected := ErrorInfo{
+		Code:      connect.CodeNotFound,
+		Message:   "user not found",
+		Procedure: testProcedure,
+		Err:       cause,
+	}
+	if infos[0] != expected {
+		t.Errorf("expected %+v, got %+v", expected, infos[0])
+	}
+}
+
+// credentialsType registers and returns a dynamic message type with user and
+// password fields, so it can be decoded from error details.
+func credentialsType(t *testing.T) protoreflect.MessageType {
+	t.Helper()
+
+	const name = "test.errdetail.Credentials"
+	if mt, err := protoregistry.GlobalTypes.FindMessageByName(name); err == nil {
+		return mt
+	}
+
+	str := descriptorpb.FieldDescriptorProto_TYPE_STRING.Enum()
+	optional := descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL.Enum()
+	file, err := protodesc.NewFile(&descriptorpb.FileDescriptorProto{
+		Name:    proto.String("test/errdetail.proto"),
+		Package: proto.String("test.errdetail"),
+		Syntax:  proto.String("proto3"),
+		MessageType: []*descriptorpb.DescriptorProto{{
+			Name: proto.String("Credentials"),
+			Field: []*descriptorpb.FieldDescriptorProto{
+				{Name: proto.String("user"), JsonName: proto.String("user"), Number: proto.Int32(1), Type: str, Label: optional},
+				{Name: proto.String("password"), JsonName: proto.String("password"), Number: proto.Int32(2), Type: str, Label: optional},
+			},
+		}},
+	}, nil)
+	if err != nil {
+		t.Fatalf("build descriptor: %v", err)
+	}
+
+	mt := dynamicpb.NewMessageType(file.Messages().ByName("Credentials"))
+	if err := protoregistry.GlobalTypes.RegisterMessage(mt); err != nil {
+		t.Fatalf("register type: %v", err)
+	}
+	return mt
+}
+
+// passwordRedactor redacts the password field of proto bodies.
+type passwordRedactor struct {
+	*DefaultRedactor
+}
+
+func (passwordRedactor) RedactBody(body any) any {
+	msg, ok := body.(proto.Message)
+	if !ok {
+		return body
+	}
+	clone := proto.Clone(msg).ProtoReflect()
+	if fd := clone.Descriptor().Fields().ByName("password"); fd != nil {
+		clone.Set(fd, protoreflect.ValueOfString(redactedValue))
+	}
+	return clone.Interface()
+}
+
+func TestErrorDetails_Redacted(t *testing.T) {
+	mt := credentialsType(t)
+	creds := mt.New()
+	creds.Set(mt.Descriptor().Fields().ByName("user"), protoreflect.ValueOfString("alice"))
+	creds.Set(mt.Descriptor().Fields().ByName("password"), protoreflect.ValueOfString("hunter2"))
+
+	connErr := connect.NewError(connect.CodeInvalidArgument, errors.New("bad credentials"))
+	detail, err := connect.NewErrorDetail(creds.Interface())
+	if err != nil {
+		t.Fatalf("new error detail: %v", err)
+	}
+	connErr.AddDetail(detail)
+	handler := func(context.Context, connect.AnyRequest) (connect.AnyResponse, error) {
+		return nil, connErr
+	}
+
+	tests := []struct {
+		name     string
+		level    slog.Level
+		redactor Redactor
+		expected []any
+	}{
+		{
+			name:     "redacted",
+			level:    slog.LevelDebug,
+			redactor: passwordRedactor{NewDefaultRedactor()},
+			expected: []any{map[string]any{
+				"@type":    "test.errdetail.Credentials",
+				"user":     "alice",
+				"password": redactedValue,
+			}},
+		},
+		{
+			name:     "not a proto message once redacted",
+			level:    slog.LevelDebug,
+			redactor: internalRedactor{NewDefaultRedactor()},
+			expected: []any{map[string]any{"@type": "test.errdetail.Credentials"}},
+		},
+		{
+			name:     "info level",
+			level:    slog.LevelInfo,
+			redactor: passwordRedactor{NewDefaultRedactor()},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logger, buf := newTestLogger(tt.level)
+			interceptor := NewLoggingInterceptor(WithLogger(logger), WithRedactor(tt.redactor))
+			_, _ = callUnary(t, interceptor, newTestRequest(testProcedure, &struct{}{}), handler)
+
+			if strings.Contains(buf.String(), "hunter2") {
+				t.Error("password must not be logged")
+			}
+			details, _ := findRecord(t, logRecords(t, buf), "request failed")["error_details"].([]any)
+			if !reflect.DeepEqual(details, tt.expected) {
+				t.Errorf("expected details %v, got %v", tt.expected, details)
+			}
+		})
+	}
+
+	// The detail returned to the client is untouched
+	value, err := connErr.Details()[0].Value()
+	if err != nil {
+		t.Fatalf("decode detail: %v", err)
+	}
+	if got := value.ProtoReflect().Get(mt.Descriptor().Fields().ByName("password")).String(); got != "hunter2" {
+		t.Errorf("expected original password to be kept, got %q", got)
+	}
+}
+
+func TestWithGenericErrorMessages(t *testing.T) {
+	logger, buf := newTestLogger(slog.LevelInfo)
+	interceptor := NewLoggingInterceptor(WithLogger(logger), WithGenericErrorMessages(true))
+
+	_, _ = callUnary(t, interceptor, newTestRequest(testProcedure, &struct{}{}), func(context.Context, connect.AnyRequest) (connect.AnyResponse, error) {
+		return nil, connect.NewError(connect.CodeNotFound, errors.New("user 42 not found in shard 7"))
+	})
+
+	record := findRecord(t, logRecords(t, buf), "request failed")
+	errAttr, _ := record["error"].(map[string]any)
+	if got := errAttr["message"]; got != "not found" {
+		t.Errorf("expected generic message, got %v", got)
+	}
+	if got := record["detail_message"]; got != "user 42 not found in shard 7" {
+		t.Errorf("expected preserved detail_message, got %v", got)
+	}
+	if errCanceled.generic || errDeadline.generic {
+		t.Error("shared context errors must not be modified")
+	}
+}
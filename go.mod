@@ -5,4 +5,5 @@ go 1.24.2
 require (
 	connectrpc.com/connect v1.18.1
 	google.golang.org/protobuf v1.36.6
+	gopkg.in/yaml.v3 v3.0.1
 )
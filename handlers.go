@@ -0,0 +1,224 @@
+package connectlog
+
+import (
+	"context"
+	"log/slog"
+	"maps"
+)
+
+// flatHandler is a slog.Handler that flattens grouped attributes into
+// dotted keys (e.g. "messages.sent") before passing records to the
+// wrapped handler, for consumers that can't parse nested objects.
+type flatHandler struct {
+	next   slog.Handler
+	prefix string
+}
+
+var _ slog.Handler = (*flatHandler)(nil)
+
+func newFlatHandler(next slog.Handler) *flatHandler {
+	return &flatHandler{next: next}
+}
+
+func (h *flatHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.next.Enabled(ctx, level)
+}
+
+func (h *flatHandler) Handle(ctx context.Context, r slog.Record) error {
+	flat := slog.NewRecord(r.Time, r.Level, r.Message, r.PC)
+	r.Attrs(func(a slog.Attr) bool {
+		flat.AddAttrs(appendFlatAttrs(nil, h.prefix, a)...)
+		return true
+	})
+	return h.next.Handle(ctx, flat)
+}
+
+func (h *flatHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	var flat []slog.Attr
+	for _, a := range attrs {
+		flat = appendFlatAttrs(flat, h.prefix, a)
+	}
+	return &flatHandler{next: h.next.WithAttrs(flat), prefix: h.prefix}
+}
+
+func (h *flatHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	return &flatHandler{next: h.next, prefix: h.prefix + name + "."}
+}
+
+// appendFlatAttrs appends a to dst, resolving log valuers and replacing
+// groups with their members keyed by the dotted group path.
+func appendFlatAttrs(dst []slog.Attr, prefix string, a slog.Attr) []slog.Attr {
+	a.Value = a.Value.Resolve()
+	if a.Value.Kind() != slog.KindGroup {
+		a.Key = prefix + a.Key
+		return append(dst, a)
+	}
+
+	// Inline groups without a key, as slog handlers do
+	groupPrefix := prefix
+	if a.Key != "" {
+		groupPrefix = prefix + a.Key + "."
+	}
+	for _, member := range a.Value.Group() {
+		dst = appendFlatAttrs(dst, groupPrefix, member)
+	}
+	return dst
+}
+
+// redactedValue replaces the values of redacted attributes.
+const redactedValue = "[REDACTED]"
+
+// redactAttrsHandler is a slog.Handler that masks the values of the named
+// top-level attributes before passing records to the wrapped handler.
+type redactAttrsHandler struct {
+	next    slog.Handler
+	keys    map[string]struct{}
+	grouped bool
+}
+
+var _ slog.Handler = (*redactAttrsHandler)(nil)
+
+func newRedactAttrsHandler(next slog.Handler, keys []string) *redactAttrsHandler {
+	set := make(map[string]struct{}, len(keys))
+	for _, key := range keys {
+		set[key] = struct{}{}
+	}
+	return &redactAttrsHandler{next: next, keys: set}
+}
+
+func (h *redactAttrsHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.next.Enabled(ctx, level)
+}
+
+func (h *redactAttrsHandler) Handle(ctx context.Context, r slog.Record) error {
+	if h.grouped {
+		return h.next.Handle(ctx, r)
+	}
+
+	redacted := slog.NewRecord(r.Time, r.Level, r.Message, r.PC)
+	r.Attrs(func(a slog.Attr) bool {
+		redacted.AddAttrs(h.redact(a))
+		return true
+	})
+	return h.next.Handle(ctx, redacted)
+}
+
+func (h *redactAttrsHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	if !h.grouped {
+		redacted := make([]slog.Attr, len(attrs))
+		for idx, a := range attrs {
+			redacted[idx] = h.redact(a)
+		}
+		attrs = redacted
+	}
+	return &redactAttrsHandler{next: h.next.WithAttrs(attrs), keys: h.keys, grouped: h.grouped}
+}
+
+func (h *redactAttrsHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	// Attributes added inside a group are no longer top-level
+	return &redactAttrsHandler{next: h.next.WithGroup(name), keys: h.keys, grouped: true}
+}
+
+func (h *redactAttrsHandler) redact(a slog.Attr) slog.Attr {
+	if _, ok := h.keys[a.Key]; ok {
+		return slog.String(a.Key, redactedValue)
+	}
+	return a
+}
+
+// forceDebugHandler is a slog.Handler that enables every level down to
+// Debug regardless of the wrapped handler's own level, used for requests
+// selected by WithDebugTrigger.
+type forceDebugHandler struct {
+	next slog.Handler
+}
+
+var _ slog.Handler = (*forceDebugHandler)(nil)
+
+func newForceDebugHandler(next slog.Handler) *forceDebugHandler {
+	return &forceDebugHandler{next: next}
+}
+
+func (h *forceDebugHandler) Enabled(_ context.Context, level slog.Level) bool {
+	return level >= slog.LevelDebug
+}
+
+func (h *forceDebugHandler) Handle(ctx context.Context, r slog.Record) error {
+	return h.next.Handle(ctx, r)
+}
+
+func (h *forceDebugHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &forceDebugHandler{next: h.next.WithAttrs(attrs)}
+}
+
+func (h *forceDebugHandler) WithGroup(name string) slog.Handler {
+	return &forceDebugHandler{next: h.next.WithGroup(name)}
+}
+
+// renamingHandler is a slog.Handler that renames attribute keys, including
+// keys nested in groups, before passing records to the wrapped handler.
+type renamingHandler struct {
+	next   slog.Handler
+	rename map[string]string
+}
+
+var _ slog.Handler = (*renamingHandler)(nil)
+
+// NewRenamingHandler returns a slog.Handler that remaps attribute keys
+// according to rename (old key -> new key) on the way to next, e.g. to map
+// "duration" to "elapsed" for an existing log schema. Keys are matched at
+// any nesting level; group names are renamed as well.
+func NewRenamingHandler(next slog.Handler, rename map[string]string) slog.Handler {
+	return &renamingHandler{next: next, rename: maps.Clone(rename)}
+}
+
+func (h *renamingHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.next.Enabled(ctx, level)
+}
+
+func (h *renamingHandler) Handle(ctx context.Context, r slog.Record) error {
+	renamed := slog.NewRecord(r.Time, r.Level, r.Message, r.PC)
+	r.Attrs(func(a slog.Attr) bool {
+		renamed.AddAttrs(h.renameAttr(a))
+		return true
+	})
+	return h.next.Handle(ctx, renamed)
+}
+
+func (h *renamingHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	renamed := make([]slog.Attr, len(attrs))
+	for idx, a := range attrs {
+		renamed[idx] = h.renameAttr(a)
+	}
+	return &renamingHandler{next: h.next.WithAttrs(renamed), rename: h.rename}
+}
+
+func (h *renamingHandler) WithGroup(name string) slog.Handler {
+	if newName, ok := h.rename[name]; ok {
+		name = newName
+	}
+	return &renamingHandler{next: h.next.WithGroup(name), rename: h.rename}
+}
+
+func (h *renamingHandler) renameAttr(a slog.Attr) slog.Attr {
+	if newKey, ok := h.rename[a.Key]; ok {
+		a.Key = newKey
+	}
+
+	a.Value = a.Value.Resolve()
+	if a.Value.Kind() == slog.KindGroup {
+		group := a.Value.Group()
+		members := make([]slog.Attr, len(group))
+		for idx, member := range group {
+			members[idx] = h.renameAttr(member)
+		}
+		a.Value = slog.GroupValue(members...)
+	}
+	return a
+}
@@ -0,0 +1,230 @@
+package connectlog
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log/slog"
+	"testing"
+
+	"connectrpc.com/connect"
+)
+
+func TestFlatHandler(t *testing.T) {
+	logger, buf := newTestLogger(slog.LevelInfo)
+	logger = slog.New(newFlatHandler(logger.Handler()))
+
+	logger.WithGroup("rpc").With(slog.String("service", "Foo")).Info("test",
+		slog.Group("messages", slog.Int("sent", 1), slog.Int("received", 2)),
+		slog.Any("error", newLoggableError(connect.NewError(connect.CodeNotFound, errors.New("missing")))),
+	)
+
+	record := logRecords(t, buf)[0]
+	expected := map[string]any{
+		"rpc.service":           "Foo",
+		"rpc.messages.sent":     float64(1),
+		"rpc.messages.received": float64(2),
+		"rpc.error.code":        "not_found",
+		"rpc.error.message":     "missing",
+	}
+	for key, want := range expected {
+		if got := record[key]; got != want {
+			t.Errorf("expected %s=%v, got %v", key, want, got)
+		}
+	}
+	if _, ok := record["rpc"]; ok {
+		t.Error("expected no nested rpc group")
+	}
+}
+
+func TestWithFlatSchema(t *testing.T) {
+	logger, buf := newTestLogger(slog.LevelInfo)
+	interceptor := NewLoggingInterceptor(WithLogger(logger), WithFlatSchema(true))
+
+	handler := interceptor.WrapStreamingHandler(func(context.Context, connect.StreamingHandlerConn) error {
+		return connect.NewError(connect.CodeInternal, errors.New("failed"))
+	})
+	_ = handler(context.Background(), newTestStreamConn(connect.StreamTypeBidi, 0))
+
+	record := findRecord(t, logRecords(t, buf), "stream failed")
+	for _, key := range []string{"messages.sent", "messages.received", "error.code", "error.message"} {
+		if _, ok := record[key]; !ok {
+			t.Errorf("expected flattened key %q in %v", key, record)
+		}
+	}
+	if _, ok := record["messages"]; ok {
+		t.Error("expected no nested messages group")
+	}
+}
+
+func TestWithRedactAttrs(t *testing.T) {
+	logger, buf := newTestLogger(slog.LevelInfo)
+	interceptor := NewLoggingInterceptor(
+		WithLogger(logger),
+		WithRedactAttrs("email"),
+		WithContextLogFn(func(context.Context) []slog.Attr {
+			return []slog.Attr{
+				slog.String("email", "alice@example.com"),
+				slog.String("tenant", "acme"),
+			}
+		}),
+	)
+
+	req := newTestRequest(testProcedure, &struct{}{})
+	_, _ = callUnary(t, interceptor, req, func(context.Context, connect.AnyRequest) (connect.AnyResponse, error) {
+		return connect.NewResponse(&struct{}{}), nil
+	})
+
+	record := findRecord(t, logRecords(t, buf), "request completed")
+	if got := record["email"]; got != "[REDACTED]" {
+		t.Errorf("expected email to be redacted, got %v", got)
+	}
+	if got := record["tenant"]; got != "acme" {
+		t.Errorf("expected tenant to be kept, got %v", got)
+	}
+}
+
+func TestRedactAttrsHandler_Groups(t *testing.T) {
+	logger, buf := newTestLogger(slog.LevelInfo)
+	logger = slog.New(newRedactAttrsHandler(logger.Handler(), []string{"email"}))
+
+	logger.Info("test", slog.String("email", "top"), slog.Group("user", slog.String("email", "nested")))
+
+	record := logRecords(t, buf)[0]
+	if got := record["email"]; got != "[REDACTED]" {
+		t.Errorf("expected top-level email to be redacted, got %v", got)
+	}
+	if got := record["user"].(map[string]any)["email"]; got != "nested" {
+		t.Errorf("expected nested email to be kept, got %v", got)
+	}
+}
+
+type debugFlagKey struct{}
+
+func TestWithDebugTrigger(t *testing.T) {
+	logger, buf := newTestLogger(slog.LevelInfo)
+	interceptor := NewLoggingInterceptor(WithLogger(logger), WithDebugTrigger(func(ctx context.Context) bool {
+		return ctx.Value(debugFlagKey{}) != nil
+	}))
+	handler := interceptor.WrapUnary(func(context.Context, connect.AnyRequest) (connect.AnyResponse, error) {
+		return connect.NewResponse(&struct{}{}), nil
+	})
+
+	// Stands in for an HTTP middleware turning the header into a context flag
+	call := func(req *testRequest) {
+		ctx := context.Background()
+		if req.Header().Get("X-Debug") == "1" {
+			ctx = context.WithValue(ctx, debugFlagKey{}, true)
+		}
+		_, _ = handler(ctx, req)
+	}
+
+	call(newTestRequest(testProcedure, &struct{}{}))
+	if records := logRecords(t, buf); len(records) != 1 {
+		t.Fatalf("expected only the completion record without trigger, got %d", len(records))
+	}
+
+	buf.Reset()
+	req := newTestRequest(testProcedure, &struct{}{})
+	req.Header().Set("X-Debug", "1")
+	call(req)
+
+	records := logRecords(t, buf)
+	findRecord(t, records, "request started")
+	findRecord(t, records, "response completed")
+	findRecord(t, records, "request completed")
+}
+
+func TestNewRenamingHandler(t *testing.T) {
+	var buf bytes.Buffer
+	handler := NewRenamingHandler(slog.NewJSONHandler(&buf, nil), map[string]string{
+		"duration": "elapsed",
+		"code":     "status",
+		"error":    "err",
+	})
+	interceptor := NewLoggingInterceptor(WithLogger(slog.New(handler)))
+
+	_, _ = callUnary(t, interceptor, newTestRequest(testProcedure, &struct{}{}), func(context.Context, connect.AnyRequest) (connect.AnyResponse, error) {
+		return nil, connect.NewError(connect.CodeNotFound, errors.New("missing"))
+	})
+
+	record := findRecord(t, logRecords(t, &buf), "request failed")
+	for _, key := range []string{"duration", "code", "error"} {
+		if _, ok := record[key]; ok {
+			t.Errorf("expected %s to be renamed", key)
+		}
+	}
+	if _, ok := record["elapsed"]; !ok {
+		t.Error("expected elapsed attribute")
+	}
+	errGroup, _ := record["err"].(map[string]any)
+	if got := errGroup["status"]; got != "not_found" {
+		t.Errorf("expected nested code renamed to status, got %v", errGroup)
+	}
+}
+
+func TestSetDebugProcedures(t *testing.T) {
+	logger, buf := newTestLogger(slog.LevelInfo)
+	interceptor := NewLoggingInterceptor(WithLogger(logger))
+	handler := func(context.Context, connect.AnyRequest) (connect.AnyResponse, error) {
+		return connect.NewResponse(&struct{}{}), nil
+	}
+	debugRecords := func(procedure string) int {
+		buf.Reset()
+		_, _ = callUnary(t, interceptor, newTestRequest(procedure, &struct{}{}), handler)
+		count := 0
+		for _, record := range logRecords(t, buf) {
+			if record[slog.LevelKey] == slog.LevelDebug.String() {
+				count++
+			}
+		}
+		return count
+	}
+
+	const other = "/acme.test.v1.TestService/Other"
+	if got := debugRecords(testProcedure); got != 0 {
+		t.Fatalf("expected no debug records by default, got %d", got)
+	}
+
+	interceptor.SetDebugProcedures(testProcedure)
+	if got := debugRecords(testProcedure); got == 0 {
+		t.Error("expected debug records for the selected procedure")
+	}
+	if got := debugRecords(other); got != 0 {
+		t.Errorf("expected no debug records for other procedures, got %d", got)
+	}
+
+	interceptor.SetDebugProcedures()
+	if got := debugRecords(testProcedure); got != 0 {
+		t.Errorf("expected no debug records after clearing, got %d", got)
+	}
+}
+
+func TestWithSchemaVersion(t *testing.T) {
+	tests := []struct {
+		version  string
+		expected string
+	}{
+		{version: "", expected: SchemaVersion},
+		{version: "2024-06", expected: "2024-06"},
+	}
+
+	for _, tt := range tests {
+		logger, buf := newTestLogger(slog.LevelDebug)
+		interceptor := NewLoggingInterceptor(WithLogger(logger), WithSchemaVersion(tt.version))
+		_, _ = callUnary(t, interceptor, newTestRequest(testProcedure, &struct{}{}), func(ctx context.Context, _ connect.AnyRequest) (connect.AnyResponse, error) {
+			LoggerFromContext(ctx).Info("handler")
+			return connect.NewResponse(&struct{}{}), nil
+		})
+
+		records := logRecords(t, buf)
+		for _, record := range records {
+			if got := record["log_schema"]; got != tt.expected {
+				t.Errorf("%q: expected log_schema %q on %v, got %v", tt.version, tt.expected, record[slog.MessageKey], got)
+			}
+		}
+		if len(records) < 4 {
+			t.Errorf("expected start, handler and completion records, got %d", len(records))
+		}
+	}
+}
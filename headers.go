@@ -1,31 +1,338 @@
 package connectlog
 
-import "strings"
+import (
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
 
-// redactHeadersMap processes headers and redacts sensitive values
-func redactHeadersMap(headers map[string][]string, redactHeaders []string) map[string][]string {
+// headerRedaction configures how headers are prepared for logging.
+type headerRedaction struct {
+	redact      []string            // redacted in addition to the built-in heuristics
+	neverRedact []string            // never redacted
+	cookies     map[string]struct{} // cookies whose values are redacted
+	maxValueLen int                 // truncation limit, <= 0 disables truncation
+	hashLong    bool                // hash values over the limit instead of truncating
+	tokenLike   bool                // redact values that look like tokens
+}
+
+// newHeaderRedaction builds the header redaction settings from options.
+func newHeaderRedaction(options Options) headerRedaction {
+	r := headerRedaction{
+		redact:      options.RedactHeaders,
+		neverRedact: options.NeverRedact,
+		maxValueLen: options.MaxHeaderValueLength,
+		hashLong:    options.HashLongHeaders,
+		tokenLike:   options.RedactTokenLikeValues,
+	}
+	if len(options.RedactCookies) > 0 {
+		r.cookies = make(map[string]struct{}, len(options.RedactCookies))
+		for _, name := range options.RedactCookies {
+			r.cookies[name] = struct{}{}
+		}
+	}
+	return r
+}
+
+// redactHeadersMap processes headers and redacts sensitive values, except
+// for the headers listed in neverRedact. With tokenLike, headers holding a
+// value that looks like a token are redacted whatever their name.
+// Non-redacted values longer than maxValueLen bytes are truncated.
+func redactHeadersMap(headers map[string][]string, r *headerRedaction) map[string][]string {
 	redacted := make(map[string][]string, len(headers))
 	for k, v := range headers {
-		if shouldRedactHeader(k, redactHeaders) {
-			redacted[k] = []string{"[REDACTED]"}
-		} else {
-			redacted[k] = v
+		switch {
+		case r.cookies != nil && isCookieHeader(k):
+			redacted[k] = truncateHeaderValues(redactCookies(k, v, r.cookies), r.maxValueLen, r.hashLong)
+		case (shouldRedactHeader(k, r.redact) || r.tokenLike && containsTokenLike(v)) &&
+			!containsFold(r.neverRedact, k):
+			redacted[k] = []string{redactedValue}
+		default:
+			redacted[k] = truncateHeaderValues(v, r.maxValueLen, r.hashLong)
 		}
 	}
 	return redacted
 }
 
+// isCookieHeader reports whether key is the Cookie or Set-Cookie header.
+func isCookieHeader(key string) bool {
+	return strings.EqualFold(key, "Cookie") || strings.EqualFold(key, "Set-Cookie")
+}
+
+// redactCookies replaces the values of the named cookies in Cookie or
+// Set-Cookie header values. Set-Cookie attributes (Path, Expires, ...) are
+// kept as is.
+func redactCookies(key string, values []string, names map[string]struct{}) []string {
+	setCookie := strings.EqualFold(key, "Set-Cookie")
+	redacted := make([]string, len(values))
+	for idx, value := range values {
+		pairs := strings.Split(value, ";")
+		for n, pair := range pairs {
+			if setCookie && n > 0 {
+				break // attributes follow the cookie itself
+			}
+			name, _, ok := strings.Cut(pair, "=")
+			if _, sensitive := names[strings.TrimSpace(name)]; ok && sensitive {
+				pairs[n] = name + "=" + redactedValue
+			}
+		}
+		redacted[idx] = strings.Join(pairs, ";")
+	}
+	return redacted
+}
+
 // shouldRedactHeader determines if a header should be redacted
 func shouldRedactHeader(key string, redactHeaders []string) bool {
-	keyLower := strings.ToLower(key)
-	for _, h := range redactHeaders {
-		if strings.EqualFold(keyLower, h) {
-			return true
-		}
+	if containsFold(redactHeaders, key) {
+		return true
 	}
 
+	keyLower := strings.ToLower(key)
 	return keyLower == "authorization" ||
 		strings.Contains(keyLower, "token") ||
 		strings.Contains(keyLower, "secret") ||
 		strings.Contains(keyLower, "password")
 }
+
+// jwtPattern matches the three base64url encoded parts of a JWT.
+var jwtPattern = regexp.MustCompile(`^[A-Za-z0-9_-]{8,}\.[A-Za-z0-9_-]{8,}\.[A-Za-z0-9_-]*$`)
+
+// minTokenLen is the length from which an opaque base64 value is treated as
+// a token by isTokenLike.
+const minTokenLen = 32
+
+// containsTokenLike reports whether any of the space separated words of
+// values looks like a token.
+func containsTokenLike(values []string) bool {
+	for _, value := range values {
+		for _, word := range strings.Fields(value) {
+			if isTokenLike(word) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// isTokenLike reports whether s is a JWT or a long base64 (standard or URL)
+// string mixing upper and lower case letters and digits. Hex identifiers
+// such as trace ids, being lower case only, are not matched.
+func isTokenLike(s string) bool {
+	if jwtPattern.MatchString(s) {
+		return true
+	}
+	if len(s) < minTokenLen {
+		return false
+	}
+
+	var upper, lower, digit bool
+	for _, c := range []byte(strings.TrimRight(s, "=")) {
+		switch {
+		case 'A' <= c && c <= 'Z':
+			upper = true
+		case 'a' <= c && c <= 'z':
+			lower = true
+		case '0' <= c && c <= '9':
+			digit = true
+		case c == '+' || c == '/' || c == '-' || c == '_':
+		default:
+			return false
+		}
+	}
+	return upper && lower && digit
+}
+
+// containsFold reports whether names contains key, ignoring case.
+func containsFold(names []string, key string) bool {
+	for _, name := range names {
+		if strings.EqualFold(name, key) {
+			return true
+		}
+	}
+	return false
+}
+
+// truncateHeaderValues returns values with every entry longer than maxLen
+// cut to a prefix followed by an ellipsis, or replaced by a hash prefix when
+// hash is set. The original slice is returned unchanged when nothing needs
+// truncating.
+func truncateHeaderValues(values []string, maxLen int, hash bool) []string {
+	if maxLen <= 0 {
+		return values
+	}
+
+	var truncated []string
+	for idx, value := range values {
+		if len(value) <= maxLen {
+			continue
+		}
+		if truncated == nil {
+			truncated = make([]string, len(values))
+			copy(truncated, values)
+		}
+		if hash {
+			truncated[idx] = hashPrefix(value)
+		} else {
+			truncated[idx] = truncateString(value, maxLen)
+		}
+	}
+
+	if truncated == nil {
+		return values
+	}
+	return truncated
+}
+
+// hashPrefixLen is the number of hex digits of the hash kept by hashPrefix.
+const hashPrefixLen = 12
+
+// hashPrefix returns a short SHA-256 prefix identifying s.
+func hashPrefix(s string) string {
+	return "sha256:" + hashPayload([]byte(s))[:hashPrefixLen] + "…"
+}
+
+// truncateString cuts s to at most maxLen bytes without splitting a UTF-8
+// sequence and appends an ellipsis.
+func truncateString(s string, maxLen int) string {
+	cut := maxLen
+	for cut > 0 && !utf8.RuneStart(s[cut]) {
+		cut--
+	}
+	return s[:cut] + "…"
+}
+
+// retryAfter parses the Retry-After header, given either in seconds or as
+// an HTTP date, into the remaining wait time.
+func retryAfter(header http.Header) (time.Duration, bool) {
+	value := strings.TrimSpace(header.Get("Retry-After"))
+	if value == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(value); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second, true
+	}
+	if date, err := http.ParseTime(value); err == nil {
+		return max(time.Until(date), 0), true
+	}
+	return 0, false
+}
+
+// contentLength returns the parsed Content-Length header value.
+func contentLength(header http.Header) (int, bool) {
+	value := header.Get("Content-Length")
+	if value == "" {
+		return 0, false
+	}
+
+	n, err := strconv.Atoi(value)
+	if err != nil || n < 0 {
+		return 0, false
+	}
+	return n, true
+}
+
+// sizeMismatchTolerance is the difference between the Content-Length and
+// the decoded request size accepted by sizeMismatch: the gRPC message
+// envelope prefix.
+const sizeMismatchTolerance = 5
+
+// sizeMismatch reports whether the declared Content-Length of an
+// uncompressed binary proto request differs from the decoded size by more
+// than sizeMismatchTolerance. The size is measured in the proto encoding, so
+// other codecs (JSON), base64-encoded grpc-web-text bodies, compressed
+// requests and requests without a valid Content-Length are never reported.
+func sizeMismatch(header http.Header, size int) bool {
+	contentType := header.Get("Content-Type")
+	if codecName(contentType) != "proto" ||
+		strings.HasPrefix(strings.ToLower(strings.TrimSpace(contentType)), "application/grpc-web-text") {
+		return false
+	}
+	n, ok := contentLength(header)
+	if !ok {
+		return false
+	}
+	for _, name := range []string{"Content-Encoding", "Grpc-Encoding", "Connect-Content-Encoding"} {
+		if encoding := header.Get(name); encoding != "" && encoding != "identity" {
+			return false
+		}
+	}
+	return abs(n-size) > sizeMismatchTolerance
+}
+
+// abs returns the absolute value of n.
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
+
+// acceptEncodingHeaders lists the headers advertising the compressions a
+// client accepts, in the order they are checked: HTTP (Connect unary),
+// Connect streaming and gRPC.
+var acceptEncodingHeaders = []string{
+	"Accept-Encoding",
+	"Connect-Accept-Encoding",
+	"Grpc-Accept-Encoding",
+}
+
+// acceptEncoding returns the compressions advertised by the client.
+func acceptEncoding(header http.Header) string {
+	for _, name := range acceptEncodingHeaders {
+		if values := header.Values(name); len(values) > 0 {
+			return strings.Join(values, ", ")
+		}
+	}
+	return ""
+}
+
+// codecPrefixes lists the Content-Type prefixes of the streaming protocols,
+// longest first, with the codec used when the type has no "+codec" suffix.
+var codecPrefixes = []struct {
+	prefix, defaultCodec string
+}{
+	{prefix: "application/grpc-web-text", defaultCodec: "proto"},
+	{prefix: "application/grpc-web", defaultCodec: "proto"},
+	{prefix: "application/grpc", defaultCodec: "proto"},
+	{prefix: "application/connect"},
+}
+
+// codecName extracts the codec from a Content-Type such as
+// "application/proto" (Connect unary), "application/connect+json" (Connect
+// streaming) or "application/grpc-web+proto". gRPC types without a suffix
+// use proto. Unrecognized content types return an empty string.
+func codecName(contentType string) string {
+	mediaType, _, _ := strings.Cut(contentType, ";")
+	mediaType = strings.ToLower(strings.TrimSpace(mediaType))
+	for _, p := range codecPrefixes {
+		rest, ok := strings.CutPrefix(mediaType, p.prefix)
+		switch {
+		case !ok:
+			continue
+		case rest == "":
+			return p.defaultCodec
+		case rest[0] == '+':
+			return rest[1:]
+		}
+		return ""
+	}
+
+	if codec, ok := strings.CutPrefix(mediaType, "application/"); ok {
+		return codec
+	}
+	return ""
+}
+
+// authScheme returns the scheme of the Authorization header (e.g. Bearer,
+// Basic) without the credentials. A value without a separate credential
+// part returns an empty string, as it may be a bare secret.
+func authScheme(header http.Header) string {
+	scheme, credentials, ok := strings.Cut(header.Get("Authorization"), " ")
+	if !ok || scheme == "" || strings.TrimSpace(credentials) == "" {
+		return ""
+	}
+	return scheme
+}
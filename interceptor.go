@@ -5,7 +5,11 @@ import (
 	"errors"
 	"io"
 	"log/slog"
+	"net/http"
+	"slices"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"connectrpc.com/connect"
@@ -14,168 +18,860 @@ import (
 // ContextLogFunc defines a function type that extracts additional log attributes from context.
 type ContextLogFunc func(context.Context) []slog.Attr
 
-// loggingInterceptor implements ConnectRPC interceptors for structured logging.
-type loggingInterceptor struct {
+// ByteCounterFunc returns the number of bytes read from and written to the
+// transport for the stream carried by ctx, as counted by user code wired at
+// the HTTP layer. It reports false when no counters are available.
+type ByteCounterFunc func(ctx context.Context) (bytesIn, bytesOut int64, ok bool)
+
+// PreHookFunc is called before a call is passed to the next handler.
+type PreHookFunc func(ctx context.Context, spec connect.Spec, peer connect.Peer)
+
+// PostHookFunc is called after a call completed with its error and duration.
+type PostHookFunc func(ctx context.Context, spec connect.Spec, peer connect.Peer, err error, duration time.Duration)
+
+// LoggingInterceptor implements ConnectRPC interceptors for structured logging.
+type LoggingInterceptor struct {
+	shuttingDown atomic.Bool
+	seq          atomic.Uint64
+	inFlight     atomic.Int64
+	debugProcs   atomic.Pointer[map[string]struct{}] // see SetDebugProcedures
+	procedures   sync.Map                            // procedure -> *procedureInfo
+
+	// options is the effective configuration, read by all the calls and
+	// never modified after New
+	options Options
+
+	// Lifecycle of background goroutines
+	ctx        context.Context
+	cancel     context.CancelFunc
+	background sync.WaitGroup
+	aggregator *aggregator
+
+	// Derived from the options by New
+	redactor      Redactor
 	logger        *slog.Logger
-	redactHeaders []string
-	contextLogFn  ContextLogFunc
+	errorLogger   *slog.Logger
+	serviceLogs   map[string]*slog.Logger
+	contextValues []contextValue
 }
 
-var _ connect.Interceptor = (*loggingInterceptor)(nil)
+var _ connect.Interceptor = (*LoggingInterceptor)(nil)
 
-// New creates a new logging interceptor instance.
+// New creates a new logging interceptor instance. Use NewLoggingInterceptor
+// to access the methods controlling its lifecycle, such as Shutdown.
 func New(opts ...Option) connect.Interceptor {
-	options := Options{
-		Logger:        slog.Default(),
-		RedactHeaders: []string{"authorization", "token"},
+	return NewLoggingInterceptor(opts...)
+}
+
+// NewLoggingInterceptor creates a new logging interceptor instance, like
+// New, returning the concrete type with its Shutdown, Close, Options and
+// SetDebugProcedures methods.
+func NewLoggingInterceptor(opts ...Option) *LoggingInterceptor {
+	// The interceptor keeps its own copy, shared neither with custom
+	// options nor with the one returned by Options
+	options := newOptions(opts).clone()
+
+	i := &LoggingInterceptor{
+		options:  options,
+		redactor: options.Redactor,
+	}
+
+	ctx := options.Context
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	i.ctx, i.cancel = context.WithCancel(ctx)
+	if options.AggregationWindow > 0 {
+		i.startAggregation(options.AggregationWindow)
+	}
+
+	// Without an explicit logger slog.Default() is resolved per request
+	if options.Logger != nil {
+		i.logger = i.wrapLogger(options.Logger)
+	}
+	if options.ErrorLogger != nil {
+		i.errorLogger = i.wrapLogger(options.ErrorLogger)
+	}
+
+	if i.redactor == nil {
+		i.redactor = newDefaultRedactor(options)
+	}
+	i.contextValues = newContextValues(options.ContextValues)
+
+	i.serviceLogs = make(map[string]*slog.Logger, len(options.ServiceLoggers))
+	for service, logger := range options.ServiceLoggers {
+		if logger != nil {
+			i.serviceLogs[service] = i.wrapLogger(logger)
+		}
+	}
+
+	return i
+}
+
+// wrapLogger applies the configured handler wrappers to logger.
+func (i *LoggingInterceptor) wrapLogger(logger *slog.Logger) *slog.Logger {
+	if i.options.FlatSchema {
+		logger = slog.New(newFlatHandler(logger.Handler()))
+	}
+	if len(i.options.RedactAttrs) > 0 {
+		logger = slog.New(newRedactAttrsHandler(logger.Handler(), i.options.RedactAttrs))
+	}
+	if i.options.SchemaVersion != "" {
+		logger = logger.With(slog.String("log_schema", i.options.SchemaVersion))
+	}
+	return logger
+}
+
+// baseLogger returns the logger for the given service: the service-specific
+// logger if configured, the explicit logger otherwise, or the current
+// slog.Default().
+func (i *LoggingInterceptor) baseLogger(service string) *slog.Logger {
+	if logger := i.serviceLogs[service]; logger != nil {
+		return logger
+	}
+	if i.logger != nil {
+		return i.logger
+	}
+	return i.wrapLogger(slog.Default())
+}
+
+// Options returns a copy of the effective configuration after defaults have
+// been applied. Changing the returned value does not affect the interceptor.
+func (i *LoggingInterceptor) Options() Options {
+	return i.options.clone()
+}
+
+// Close stops the background goroutines of the interceptor and waits for
+// them to exit. The interceptor keeps logging calls after Close.
+func (i *LoggingInterceptor) Close() error {
+	i.cancel()
+	i.background.Wait()
+	return nil
+}
+
+// goBackground runs fn in a goroutine tracked by Close. fn must return once
+// its context is done.
+func (i *LoggingInterceptor) goBackground(fn func(ctx context.Context)) {
+	i.background.Add(1)
+	go func() {
+		defer i.background.Done()
+		fn(i.ctx)
+	}()
+}
+
+// SetDebugProcedures enables debug logging for calls to the given
+// procedures (e.g. "/acme.foo.v1.FooService/Bar"), regardless of the logger
+// level, for live troubleshooting. Each call replaces the previous set;
+// calling it without procedures disables it. It is safe to call while the
+// interceptor is serving calls.
+func (i *LoggingInterceptor) SetDebugProcedures(procedures ...string) {
+	if len(procedures) == 0 {
+		i.debugProcs.Store(nil)
+		return
 	}
 
-	for _, opt := range opts {
-		opt(&options)
+	set := make(map[string]struct{}, len(procedures))
+	for _, procedure := range procedures {
+		set[strings.TrimPrefix(procedure, "/")] = struct{}{}
 	}
+	i.debugProcs.Store(&set)
+}
 
-	// disable logging
-	if options.Logger == nil {
-		options.Logger = slog.New(slog.DiscardHandler)
+// debugProcedure reports whether procedure was selected by
+// SetDebugProcedures.
+func (i *LoggingInterceptor) debugProcedure(procedure string) bool {
+	set := i.debugProcs.Load()
+	if set == nil {
+		return false
 	}
+	_, ok := (*set)[strings.TrimPrefix(procedure, "/")]
+	return ok
+}
 
-	return &loggingInterceptor{
-		logger:        options.Logger,
-		redactHeaders: options.RedactHeaders,
-		contextLogFn:  options.ContextLogFn,
+// Shutdown marks the server as shutting down. Streams canceled after this
+// call are logged at Info with reason "shutdown" instead of as failures.
+func (i *LoggingInterceptor) Shutdown() {
+	i.shuttingDown.Store(true)
+}
+
+// RequestAttrs returns the attributes identifying a call that the
+// interceptor adds to all of its logs (service, method, protocol and addr),
+// for reuse in custom interceptors or handlers.
+func RequestAttrs(spec connect.Spec, peer connect.Peer) []slog.Attr {
+	return requestAttrs(parseProcedure(spec.Procedure, false), peer, false, 0)
+}
+
+// requestAttrs builds the procedure and peer attributes with spare capacity
+// for extra attributes.
+func requestAttrs(info *procedureInfo, peer connect.Peer, semconv bool, extra int) []slog.Attr {
+	attrs := make([]slog.Attr, 0, len(info.attrs)+3+extra)
+	attrs = append(attrs, info.attrs...)
+	// In-memory transports leave the peer empty
+	if peer.Protocol != "" {
+		if semconv {
+			attrs = append(attrs, slog.String("rpc.system", rpcSystem(peer.Protocol)))
+		}
+		attrs = append(attrs, slog.String("protocol", normalizeProtocol(peer.Protocol)))
+	}
+	if peer.Addr != "" {
+		attrs = append(attrs, slog.String("addr", peer.Addr))
 	}
+	return attrs
 }
 
-// initRequestLogger initializes the base logger with common request attributes
-func (i *loggingInterceptor) initRequestLogger(ctx context.Context, spec connect.Spec, peer connect.Peer) *slog.Logger {
-	procedure := strings.TrimPrefix(spec.Procedure, "/")
-	idx := strings.Index(procedure, "/")
-	service, method := procedure[:idx], procedure[idx+1:]
+// initRequestLogger initializes the base logger with common request
+// attributes, together with the logger for failures carrying the same
+// attributes (the same logger unless WithErrorLogger is set).
+//
+// The attributes are always added in the same order: procedure and peer,
+// the option-derived attributes in the order below, the WithAttrsFunc
+// attributes, context values sorted by name, then the WithContextLogFn
+// attributes. Functions' attributes keep the order they are returned in.
+func (i *LoggingInterceptor) initRequestLogger(ctx context.Context, spec connect.Spec, peer connect.Peer, header http.Header) (logger, errLogger *slog.Logger) {
+	info := i.procedureInfo(spec.Procedure)
+
+	attrs := requestAttrs(info, peer, i.options.SemanticConventions, 9)
+	if i.options.CombinedRPCAttr {
+		attrs = append(attrs, slog.String("rpc", info.rpc))
+	}
+	if i.options.Environment != "" {
+		attrs = append(attrs, slog.String("env", i.options.Environment))
+	}
+
+	if i.options.LogAcceptEncoding {
+		if encoding := acceptEncoding(header); encoding != "" {
+			attrs = append(attrs, slog.String("accept_encoding", encoding))
+		}
+	}
+
+	if i.options.LogAuthScheme {
+		if scheme := authScheme(header); scheme != "" {
+			attrs = append(attrs, slog.String("auth_scheme", scheme))
+		}
+	}
+
+	if i.options.LogCodec {
+		if codec := codecName(header.Get("Content-Type")); codec != "" {
+			attrs = append(attrs, slog.String("codec", codec))
+		}
+	}
 
-	logger := i.logger.With(
-		slog.String("service", service),
-		slog.String("method", method),
-		slog.String("protocol", peer.Protocol),
-		slog.String("addr", peer.Addr),
-	)
+	if i.options.LogIdempotency && spec.IdempotencyLevel != connect.IdempotencyUnknown {
+		attrs = append(attrs, slog.String("idempotency_level", spec.IdempotencyLevel.String()))
+	}
+
+	if i.options.LogTLSPeer {
+		attrs = append(attrs, tlsPeerAttrs(ctx)...)
+	}
+
+	if i.options.RouteFn != nil {
+		if route := i.options.RouteFn(ctx); route != "" {
+			attrs = append(attrs, slog.String("route", route))
+		}
+	}
+
+	if i.options.TenantFn != nil {
+		if tenant := i.options.TenantFn(ctx); tenant != "" {
+			attrs = append(attrs, slog.String("tenant", tenant))
+		}
+	}
+
+	// Add host-level fields if configured
+	if i.options.AttrsFn != nil {
+		attrs = append(attrs, i.options.AttrsFn()...)
+	}
+
+	attrs = appendContextValues(ctx, attrs, i.contextValues)
 
 	// Add custom fields from context if configured
-	if i.contextLogFn != nil {
-		for _, attr := range i.contextLogFn(ctx) {
-			logger = logger.With(attr)
+	if i.options.ContextLogFn != nil {
+		attrs = append(attrs, i.options.ContextLogFn(ctx)...)
+	}
+
+	debug := i.options.DebugTrigger != nil && i.options.DebugTrigger(ctx) || i.debugProcedure(spec.Procedure)
+	newLogger := func(base *slog.Logger) *slog.Logger {
+		handler := base.Handler()
+		if debug {
+			handler = newForceDebugHandler(handler)
 		}
+		return slog.New(handler.WithAttrs(attrs))
 	}
 
-	return logger
+	logger = newLogger(i.baseLogger(info.service))
+	errLogger = logger
+	if i.errorLogger != nil {
+		errLogger = newLogger(i.errorLogger)
+	}
+	return logger, errLogger
+}
+
+// normalizeProtocol maps a Connect protocol name to the logged protocol
+// value: "connect", "grpc" or "grpc_web". Other protocols are lowercased.
+func normalizeProtocol(protocol string) string {
+	switch protocol {
+	case connect.ProtocolGRPCWeb:
+		return "grpc_web"
+	default:
+		return strings.ToLower(protocol)
+	}
+}
+
+// rpcSystem maps a Connect protocol name to the OpenTelemetry rpc.system value.
+func rpcSystem(protocol string) string {
+	switch protocol {
+	case connect.ProtocolGRPC, connect.ProtocolGRPCWeb:
+		return "grpc"
+	case connect.ProtocolConnect:
+		return "connect_rpc"
+	default:
+		return protocol
+	}
+}
+
+// appendDeadline appends deadline_in, the time left until the context
+// deadline, when enabled and the context has a deadline.
+func (i *LoggingInterceptor) appendDeadline(ctx context.Context, attrs []any) []any {
+	if !i.options.LogDeadline {
+		return attrs
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		attrs = append(attrs, slog.Duration("deadline_in", time.Until(deadline)))
+	}
+	return attrs
+}
+
+// startSpan adds a new span_id to both loggers and logs the "rpc.start"
+// record of WithSpanStyleLogging with attrs.
+func (i *LoggingInterceptor) startSpan(ctx context.Context, logger, errLogger *slog.Logger, attrs ...any) (*slog.Logger, *slog.Logger) {
+	spanID := slog.String("span_id", newSpanID())
+	logger, errLogger = logger.With(spanID), errLogger.With(spanID)
+	logger.InfoContext(ctx, "rpc.start", attrs...)
+	return logger, errLogger
+}
+
+// completionMessage returns msg, or the compact summary of the call when
+// WithSummaryMessage is enabled, e.g. "POST FooService/Bar ok 12ms".
+func (i *LoggingInterceptor) completionMessage(msg, httpMethod string, spec connect.Spec, code string, duration time.Duration) string {
+	if i.options.SpanStyleLogging {
+		return "rpc.end"
+	}
+	if !i.options.SummaryMessage {
+		return msg
+	}
+
+	var sb strings.Builder
+	if httpMethod != "" {
+		sb.WriteString(httpMethod)
+		sb.WriteByte(' ')
+	}
+	sb.WriteString(i.procedureInfo(spec.Procedure).rpc)
+	sb.WriteByte(' ')
+	sb.WriteString(code)
+	sb.WriteByte(' ')
+	sb.WriteString(i.roundDuration(duration).String())
+	return sb.String()
+}
+
+// appendCacheHit appends cache_hit when the configured function reports it.
+func (i *LoggingInterceptor) appendCacheHit(ctx context.Context, attrs []any) []any {
+	if i.options.CacheHitFn == nil {
+		return attrs
+	}
+	if hit, ok := i.options.CacheHitFn(ctx); ok {
+		attrs = append(attrs, slog.Bool("cache_hit", hit))
+	}
+	return attrs
+}
+
+// appendInFlight appends the number of calls in progress, including the
+// one being logged, when WithConcurrencyLogging is enabled.
+func (i *LoggingInterceptor) appendInFlight(attrs []any) []any {
+	if !i.options.ConcurrencyLogging {
+		return attrs
+	}
+	return append(attrs, slog.Int64("in_flight", i.inFlight.Load()))
+}
+
+// appendSlow flags calls lasting at least threshold with slow, unless
+// threshold is 0.
+func appendSlow(attrs []any, duration, threshold time.Duration) []any {
+	if threshold <= 0 || duration < threshold {
+		return attrs
+	}
+	return append(attrs, slog.Bool("slow", true))
+}
+
+// appendSeq appends the next sequence number when enabled.
+func (i *LoggingInterceptor) appendSeq(attrs []any) []any {
+	if !i.options.SequenceNumbers {
+		return attrs
+	}
+	return append(attrs, slog.Uint64("seq", i.seq.Add(1)))
+}
+
+// transportAttr returns the transport group with the HTTP method, omitted
+// when not yet known, and the stream type.
+func transportAttr(method string, streamType connect.StreamType) slog.Attr {
+	attrs := make([]any, 0, 2)
+	if method != "" {
+		attrs = append(attrs, slog.String("method", method))
+	}
+	attrs = append(attrs, slog.String("stream_type", streamType.String()))
+	return slog.Group("transport", attrs...)
+}
+
+// errorAttrs returns the attributes describing a failed call. The decoded
+// error details are included, like bodies, only when debug is enabled.
+func (i *LoggingInterceptor) errorAttrs(ctx context.Context, connErr *loggableError, debug bool) []any {
+	if i.options.GenericErrorMessages {
+		// Copy, as context errors share singletons
+		connErr = &loggableError{Error: connErr.Error, generic: true}
+	}
+
+	attrs := []any{
+		slog.Any("error", connErr),
+		slog.String("error_class", errorClass(connErr.Code())),
+	}
+	if connErr.generic {
+		attrs = append(attrs, slog.String("detail_message", connErr.Message()))
+	}
+
+	if i.options.SemanticConventions {
+		attrs = append(attrs, slog.Int("rpc.grpc.status_code", int(connErr.Code())))
+	}
+
+	if i.options.LogErrorMeta {
+		if meta := connErr.Meta(); len(meta) > 0 {
+			attrs = append(attrs, slog.Any("error_meta", i.redactedHeaders(meta)))
+		}
+	}
+
+	switch connErr.Code() {
+	case connect.CodeResourceExhausted, connect.CodeUnavailable:
+		if retryAfter, ok := retryAfter(connErr.Meta()); ok {
+			attrs = append(attrs, slog.Duration("retry_after", retryAfter))
+		}
+	}
+
+	if debug {
+		if details := i.errorDetails(connErr); len(details) > 0 {
+			attrs = append(attrs, slog.Any("error_details", details))
+		}
+	}
+
+	if i.options.LogCancelSource {
+		switch connErr.Code() {
+		case connect.CodeCanceled, connect.CodeDeadlineExceeded:
+			attrs = append(attrs, slog.String("cancel_source", cancelSource(ctx)))
+		}
+	}
+
+	return attrs
+}
+
+// redactedHeaders returns headers prepared for logging.
+func (i *LoggingInterceptor) redactedHeaders(headers map[string][]string) map[string][]string {
+	return i.redactor.RedactHeaders(headers)
+}
+
+// logBodyFor reports whether debug bodies are logged for calls resolving
+// to code (0 for success).
+func (i *LoggingInterceptor) logBodyFor(code connect.Code) bool {
+	return len(i.options.BodyLoggingCodes) == 0 || slices.Contains(i.options.BodyLoggingCodes, code)
+}
+
+// loggedResponse returns the response body as it should be logged.
+func (i *LoggingInterceptor) loggedResponse(msg any) any {
+	if i.options.ResponseTransformer == nil {
+		return msg
+	}
+	return i.options.ResponseTransformer(msg)
+}
+
+// roundDuration rounds d to the configured granularity for logging.
+func (i *LoggingInterceptor) roundDuration(d time.Duration) time.Duration {
+	if i.options.DurationRounding <= 0 {
+		return d
+	}
+	return d.Round(i.options.DurationRounding)
+}
+
+// errorLevel returns the log level for a failed call with the given code.
+func (i *LoggingInterceptor) errorLevel(code connect.Code) slog.Level {
+	switch {
+	case i.options.QuietAuthErrors && (code == connect.CodeUnauthenticated || code == connect.CodePermissionDenied):
+		return slog.LevelInfo
+	case code < connect.CodeInternal:
+		return slog.LevelWarn
+	default:
+		return slog.LevelError
+	}
 }
 
 // WrapUnary implements unary request/response logging middleware.
-func (i *loggingInterceptor) WrapUnary(next connect.UnaryFunc) connect.UnaryFunc {
+func (i *LoggingInterceptor) WrapUnary(next connect.UnaryFunc) connect.UnaryFunc {
 	return func(ctx context.Context, req connect.AnyRequest) (connect.AnyResponse, error) {
 		start := time.Now()
-		logger := i.initRequestLogger(ctx, req.Spec(), req.Peer())
+		if i.options.ConcurrencyLogging {
+			i.inFlight.Add(1)
+			defer i.inFlight.Add(-1)
+		}
+		logger, errLogger := i.initRequestLogger(ctx, req.Spec(), req.Peer(), req.Header())
+
+		requestID := i.requestID(req.Header())
+		if requestID != "" {
+			logger = logger.With(slog.String("request_id", requestID))
+			errLogger = errLogger.With(slog.String("request_id", requestID))
+			if req.Spec().IsClient {
+				// Propagate the id to the server
+				req.Header().Set(i.options.RequestIDHeader, requestID)
+			}
+		}
+		if i.options.SpanStyleLogging {
+			var attrs []any
+			if len(i.options.BodyLoggingCodes) == 0 && logger.Enabled(ctx, slog.LevelDebug) {
+				attrs = append(attrs, i.bodyAttr("request", req.Any()))
+			}
+			logger, errLogger = i.startSpan(ctx, logger, errLogger, attrs...)
+		}
+		ctx = contextWithLogger(ctx, logger)
+		ctx, collector := contextWithCollector(ctx)
+		defer collector.drain()
+
+		// Only clients have the calling user code on the stack; server
+		// handlers are located when they call RecordCaller
+		var caller string
+		if i.options.CallerInfo {
+			if req.Spec().IsClient {
+				caller = callerInfo()
+			} else {
+				collector.wantCaller = true
+			}
+		}
 
 		// Debug logging for request start with headers and body
 		if logger.Enabled(ctx, slog.LevelDebug) {
-			headers := redactHeadersMap(req.Header(), i.redactHeaders)
-			logger.DebugContext(ctx, "request started",
-				slog.Any("request", req.Any()),
-				slog.Any("headers", headers),
-			)
+			attrs := make([]any, 0, 3)
+			if len(i.options.BodyLoggingCodes) == 0 {
+				attrs = append(attrs, i.bodyAttr("request", req.Any()))
+				attrs = i.appendCodecSizes(attrs, "request", req.Any())
+			}
+			attrs = append(attrs, slog.Any("headers", i.redactedHeaders(req.Header())))
+			attrs = i.appendDeadline(ctx, attrs)
+			logger.DebugContext(ctx, "request started", attrs...)
+		}
+
+		if i.options.PreHook != nil {
+			i.options.PreHook(ctx, req.Spec(), req.Peer())
 		}
 
 		// Execute the RPC call
-		res, err := next(ctx, req)
+		handlerStart := time.Now()
+		res, err := i.callUnary(ctx, errLogger, next, req)
+		handlerDuration := time.Since(handlerStart)
+		duration := time.Since(start)
+
+		if i.options.PostHook != nil {
+			i.options.PostHook(ctx, req.Spec(), req.Peer(), err, duration)
+		}
+		i.aggregate(req.Spec().Procedure, err, duration)
+
+		if err != nil {
+			// Run error side effects once the call has been logged
+			defer i.notifyError(ctx, req.Spec(), err)
+		}
+
+		// The call is logged with the handler's error and answered with
+		// resErr, which carries the echoed request id
+		resErr := err
+		if !req.Spec().IsClient {
+			resErr = i.echoRequestID(requestID, res, err)
+		}
+
+		// A logged rpc.start is always paired with its rpc.end
+		if !i.options.SpanStyleLogging && !i.sampled(ctx, resultCode(err), duration) {
+			return res, resErr
+		}
 
 		// Prepare log attributes
 		logAttrs := []any{
-			slog.Duration("duration", time.Since(start)),
+			slog.Duration("duration", i.roundDuration(duration)),
+		}
+		if i.options.PhaseTiming {
+			logAttrs = append(logAttrs, slog.Duration("handler_duration", i.roundDuration(handlerDuration)))
+		}
+		logAttrs = i.appendSeq(logAttrs)
+		logAttrs = i.appendInFlight(logAttrs)
+		logAttrs = appendSlow(logAttrs, duration, i.options.SlowThreshold)
+
+		if i.options.UniformMessagesGroup {
+			sent := 0
+			if err == nil {
+				sent = 1
+			}
+			logAttrs = append(logAttrs, slog.Group("messages",
+				slog.Int("sent", sent),
+				slog.Int("received", 1),
+			))
 		}
 
 		// Add payload sizes if available
-		if reqSize := calculateSize(req.Any()); reqSize >= 0 {
+		reqSize := calculateSize(req.Any())
+		if reqSize >= 0 {
 			logAttrs = append(logAttrs, slog.Int("request_size", reqSize))
 		}
+		if i.options.RequestBodyHash {
+			if data, ok := marshalPayload(req.Any()); ok {
+				logAttrs = append(logAttrs, slog.String("request_hash", hashPayload(data)))
+			}
+		}
+
+		largeRequest := i.options.LargeRequestThreshold > 0 && reqSize > i.options.LargeRequestThreshold
+		if largeRequest {
+			logAttrs = append(logAttrs, slog.Bool("large_request", true))
+		}
+
+		if i.options.LogContentLength {
+			if n, ok := contentLength(req.Header()); ok {
+				logAttrs = append(logAttrs, slog.Int("content_length", n))
+			}
+		}
+		if i.options.DetectSizeMismatch && reqSize >= 0 && sizeMismatch(req.Header(), reqSize) {
+			logAttrs = append(logAttrs, slog.Bool("size_mismatch", true))
+		}
+
+		if i.options.LogTransportDetails {
+			logAttrs = append(logAttrs, transportAttr(req.HTTPMethod(), req.Spec().StreamType))
+		}
+
+		if caller == "" {
+			caller = collector.handlerCaller()
+		}
+		if caller != "" {
+			logAttrs = append(logAttrs, slog.String("caller", caller))
+		}
+
+		logAttrs = i.appendCacheHit(ctx, logAttrs)
+
+		// Attributes added by the handler with AddAttr
+		logAttrs = append(logAttrs, collector.drain()...)
+
+		if i.options.LogMessageTypes {
+			if req.Any() != nil {
+				logAttrs = append(logAttrs, slog.String("request_type", messageTypeName(req.Any())))
+			}
+			if res != nil && res.Any() != nil {
+				logAttrs = append(logAttrs, slog.String("response_type", messageTypeName(res.Any())))
+			}
+		}
 
 		if err != nil {
 			// Handle different error types
 			connErr := newLoggableError(err)
-			logAttrs = append(logAttrs, slog.Any("error", connErr))
+			logAttrs = append(logAttrs, i.errorAttrs(ctx, connErr, logger.Enabled(ctx, slog.LevelDebug))...)
+
+			// Request body of a failure selected by WithBodyLoggingCodes
+			if len(i.options.BodyLoggingCodes) > 0 && i.logBodyFor(connErr.Code()) && logger.Enabled(ctx, slog.LevelDebug) {
+				attrs := i.appendCodecSizes([]any{i.bodyAttr("request", req.Any())}, "request", req.Any())
+				logger.DebugContext(ctx, "response failed", attrs...)
+			}
 
 			// Determine log level based on error type
-			if connErr.Code() < connect.CodeInternal {
-				logger.Warn("request failed", logAttrs...)
-			} else {
-				logger.Error("request failed", logAttrs...)
+			level := i.errorLevel(connErr.Code())
+			if largeRequest {
+				level = max(level, slog.LevelWarn)
 			}
+			errLogger.Log(ctx, level, i.completionMessage("request failed", req.HTTPMethod(), req.Spec(), connErr.Code().String(), duration), logAttrs...)
 		} else {
 			// Debug logging for response with headers
 			if logger.Enabled(ctx, slog.LevelDebug) {
-				headers := redactHeadersMap(res.Header(), i.redactHeaders)
-				logger.DebugContext(ctx, "response completed",
-					slog.Any("response", res.Any()),
-					slog.Any("headers", headers),
-				)
+				attrs := make([]any, 0, 3)
+				if i.logBodyFor(0) {
+					if len(i.options.BodyLoggingCodes) > 0 {
+						attrs = append(attrs, i.bodyAttr("request", req.Any()))
+						attrs = i.appendCodecSizes(attrs, "request", req.Any())
+					}
+					attrs = append(attrs, i.bodyAttr("response", i.loggedResponse(res.Any())))
+					attrs = i.appendCodecSizes(attrs, "response", res.Any())
+				}
+				attrs = append(attrs, slog.Any("headers", i.redactedHeaders(res.Header())))
+				logger.DebugContext(ctx, "response completed", attrs...)
 			}
 
 			// Success case logging
 			if resSize := calculateSize(res.Any()); resSize >= 0 {
 				logAttrs = append(logAttrs, slog.Int("response_size", resSize))
 			}
+			if i.options.SpanStyleLogging && i.logBodyFor(0) && logger.Enabled(ctx, slog.LevelDebug) {
+				logAttrs = append(logAttrs, i.bodyAttr("response", i.loggedResponse(res.Any())))
+			}
+
+			logAttrs = append(logAttrs, slog.String("code", codeOK))
+			if i.options.SemanticConventions {
+				logAttrs = append(logAttrs, slog.Int("rpc.grpc.status_code", 0))
+			}
 
-			logger.InfoContext(ctx, "request completed", logAttrs...)
+			level := slog.LevelInfo
+			if largeRequest {
+				level = slog.LevelWarn
+			}
+			logger.Log(ctx, level, i.completionMessage("request completed", req.HTTPMethod(), req.Spec(), codeOK, duration), logAttrs...)
 		}
 
-		return res, err
+		return res, resErr
 	}
 }
 
 // WrapStreamingHandler implements streaming request logging middleware.
-func (i *loggingInterceptor) WrapStreamingHandler(next connect.StreamingHandlerFunc) connect.StreamingHandlerFunc {
+func (i *LoggingInterceptor) WrapStreamingHandler(next connect.StreamingHandlerFunc) connect.StreamingHandlerFunc {
 	return func(ctx context.Context, conn connect.StreamingHandlerConn) error {
 		start := time.Now()
-		logger := i.initRequestLogger(ctx, conn.Spec(), conn.Peer())
+		if i.options.ConcurrencyLogging {
+			i.inFlight.Add(1)
+			defer i.inFlight.Add(-1)
+		}
+		logger, errLogger := i.initRequestLogger(ctx, conn.Spec(), conn.Peer(), conn.RequestHeader())
+
+		if requestID := i.requestID(conn.RequestHeader()); requestID != "" {
+			logger = logger.With(slog.String("request_id", requestID))
+			errLogger = errLogger.With(slog.String("request_id", requestID))
+			conn.ResponseHeader().Set(i.options.RequestIDHeader, requestID)
+		}
+		if i.options.SpanStyleLogging {
+			logger, errLogger = i.startSpan(ctx, logger, errLogger)
+		}
+		ctx = contextWithLogger(ctx, logger)
+		ctx, collector := contextWithCollector(ctx)
+		defer collector.drain()
 
 		// Debug logging for stream start with headers
 		if logger.Enabled(ctx, slog.LevelDebug) {
-			headers := redactHeadersMap(conn.RequestHeader(), i.redactHeaders)
-			logger.DebugContext(ctx, "stream started",
-				slog.Any("headers", headers),
-			)
+			attrs := []any{
+				slog.Any("headers", i.redactedHeaders(conn.RequestHeader())),
+			}
+			attrs = i.appendDeadline(ctx, attrs)
+			logger.DebugContext(ctx, "stream started", attrs...)
 		}
 
 		// Wrap the connection to log messages
-		wrappedConn := newLoggedStreamConn(ctx, conn, logger)
+		wrappedConn := newLoggedStreamConn(ctx, conn, logger, i)
+		defer wrappedConn.done() // also when the handler panics
+		wrappedConn.startSummaries()
+
+		if i.options.PreHook != nil {
+			i.options.PreHook(ctx, conn.Spec(), conn.Peer())
+		}
 
 		// Execute the stream
-		err := next(ctx, wrappedConn)
+		err := i.callStream(ctx, errLogger, next, wrappedConn)
+		duration := time.Since(start)
+		wrappedConn.done()
+
+		if i.options.PostHook != nil {
+			i.options.PostHook(ctx, conn.Spec(), conn.Peer(), err, duration)
+		}
+		i.aggregate(conn.Spec().Procedure, err, duration)
+
+		failed := err != nil && !errors.Is(err, io.EOF)
+		if failed {
+			// Run error side effects once the stream has been logged
+			defer i.notifyError(ctx, conn.Spec(), err)
+		}
+		// A logged rpc.start is always paired with its rpc.end
+		if !i.options.SpanStyleLogging && !i.sampled(ctx, resultCode(err), duration) {
+			return err
+		}
+
+		// Skip streams that completed without exchanging any messages
+		if i.options.SkipEmptyStreams && !i.options.SpanStyleLogging && !failed && wrappedConn.sentCount == 0 && wrappedConn.receivedCount == 0 {
+			return err
+		}
 
 		logAttrs := []any{
 			slog.Group("messages",
 				slog.Int("sent", wrappedConn.sentCount),
 				slog.Int("received", wrappedConn.receivedCount),
 			),
-			slog.Duration("duration", time.Since(start)),
+			slog.Duration("duration", i.roundDuration(duration)),
+			slog.Duration("active_duration", i.roundDuration(wrappedConn.active)),
+		}
+		logAttrs = i.appendSeq(logAttrs)
+		logAttrs = i.appendInFlight(logAttrs)
+		logAttrs = appendSlow(logAttrs, duration, i.options.SlowStreamThreshold)
+
+		if i.options.LogContentLength {
+			if n, ok := contentLength(conn.RequestHeader()); ok {
+				logAttrs = append(logAttrs, slog.Int("content_length", n))
+			}
+		}
+
+		if i.options.ByteCounter != nil {
+			if bytesIn, bytesOut, ok := i.options.ByteCounter(ctx); ok {
+				logAttrs = append(logAttrs,
+					slog.Int64("wire_bytes_in", bytesIn),
+					slog.Int64("wire_bytes_out", bytesOut),
+				)
+			}
+		}
+
+		if i.options.LogTransportDetails {
+			// Streaming calls are always sent as POST
+			logAttrs = append(logAttrs, transportAttr(http.MethodPost, conn.Spec().StreamType))
 		}
 
-		if err != nil && !errors.Is(err, io.EOF) {
+		if wrappedConn.caller != "" {
+			logAttrs = append(logAttrs, slog.String("caller", wrappedConn.caller))
+		}
+
+		logAttrs = i.appendCacheHit(ctx, logAttrs)
+
+		// Attributes added by the handler with AddAttr
+		logAttrs = append(logAttrs, collector.drain()...)
+
+		if i.options.LogMessageTypes {
+			if reqType, resType, ok := streamMessageTypes(conn.Spec().Schema); ok {
+				logAttrs = append(logAttrs,
+					slog.String("request_type", reqType),
+					slog.String("response_type", resType),
+				)
+			}
+		}
+
+		if failed {
 			connErr := newLoggableError(err)
-			logAttrs = append(logAttrs, slog.Any("error", connErr))
+			logAttrs = append(logAttrs, i.errorAttrs(ctx, connErr, logger.Enabled(ctx, slog.LevelDebug))...)
+
+			// Message counts when sending or receiving first failed, which
+			// the handler may have outlived; sent_any tells failures before
+			// the first response from partial successes
+			if wrappedConn.failed {
+				logAttrs = append(logAttrs,
+					slog.Int("failed_at_sent", wrappedConn.failedSent),
+					slog.Int("failed_at_received", wrappedConn.failedReceived),
+				)
+			}
+			logAttrs = append(logAttrs, slog.Bool("sent_any", wrappedConn.sentCount > 0))
 
-			if connErr.Code() < connect.CodeInternal {
-				logger.WarnContext(ctx, "stream failed", logAttrs...)
-			} else {
-				logger.ErrorContext(ctx, "stream failed", logAttrs...)
+			level := i.errorLevel(connErr.Code())
+			if connErr.Code() == connect.CodeCanceled && i.shuttingDown.Load() {
+				logAttrs = append(logAttrs, slog.String("reason", "shutdown"))
+				level = slog.LevelInfo
 			}
+			errLogger.Log(ctx, level, i.completionMessage("stream failed", http.MethodPost, conn.Spec(), connErr.Code().String(), duration), logAttrs...)
 		} else {
-			logger.InfoContext(ctx, "stream completed", logAttrs...)
+			logAttrs = append(logAttrs, slog.String("code", codeOK))
+			if i.options.SemanticConventions {
+				logAttrs = append(logAttrs, slog.Int("rpc.grpc.status_code", 0))
+			}
+
+			logger.InfoContext(ctx, i.completionMessage("stream completed", http.MethodPost, conn.Spec(), codeOK, duration), logAttrs...)
 		}
 
 		return err
 	}
 }
 
-func (i *loggingInterceptor) WrapStreamingClient(next connect.StreamingClientFunc) connect.StreamingClientFunc {
+func (i *LoggingInterceptor) WrapStreamingClient(next connect.StreamingClientFunc) connect.StreamingClientFunc {
 	return connect.StreamingClientFunc(func(ctx context.Context, spec connect.Spec) connect.StreamingClientConn {
 		return next(ctx, spec)
 	})
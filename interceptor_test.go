@@ -0,0 +1,1216 @@
+package connectlog
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"reflect"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"connectrpc.com/connect"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+const testProcedure = "/acme.test.v1.TestService/Call"
+
+// testRequest overrides the spec, peer and HTTP method of a connect.Request,
+// which are normally filled in by the Connect runtime.
+type testRequest struct {
+	connect.AnyRequest
+	spec   connect.Spec
+	peer   connect.Peer
+	method string
+}
+
+func newTestRequest[T any](procedure string, msg *T) *testRequest {
+	return &testRequest{
+		AnyRequest: connect.NewRequest(msg),
+		spec:       connect.Spec{Procedure: procedure, StreamType: connect.StreamTypeUnary},
+		peer:       connect.Peer{Addr: "127.0.0.1:12345", Protocol: connect.ProtocolConnect},
+	}
+}
+
+func (r *testRequest) Spec() connect.Spec { return r.spec }
+func (r *testRequest) Peer() connect.Peer { return r.peer }
+func (r *testRequest) HTTPMethod() string { return r.method }
+
+// testStreamConn is an in-memory connect.StreamingHandlerConn that yields
+// a fixed number of received messages before io.EOF.
+type testStreamConn struct {
+	spec            connect.Spec
+	peer            connect.Peer
+	requestHeader   http.Header
+	responseHeader  http.Header
+	responseTrailer http.Header
+	incoming        int
+	sendDelay       time.Duration
+	sendLimit       int // Send fails after this many messages; 0 is unlimited
+	sent            int
+}
+
+func newTestStreamConn(streamType connect.StreamType, incoming int) *testStreamConn {
+	return &testStreamConn{
+		spec:            connect.Spec{Procedure: testProcedure, StreamType: streamType},
+		peer:            connect.Peer{Addr: "127.0.0.1:12345", Protocol: connect.ProtocolGRPC},
+		requestHeader:   make(http.Header),
+		responseHeader:  make(http.Header),
+		responseTrailer: make(http.Header),
+		incoming:        incoming,
+	}
+}
+
+func (c *testStreamConn) Spec() connect.Spec           { return c.spec }
+func (c *testStreamConn) Peer() connect.Peer           { return c.peer }
+func (c *testStreamConn) RequestHeader() http.Header   { return c.requestHeader }
+func (c *testStreamConn) ResponseHeader() http.Header  { return c.responseHeader }
+func (c *testStreamConn) ResponseTrailer() http.Header { return c.responseTrailer }
+
+func (c *testStreamConn) Send(any) error {
+	time.Sleep(c.sendDelay)
+	if c.sendLimit > 0 && c.sent >= c.sendLimit {
+		return connect.NewError(connect.CodeUnavailable, errors.New("broken pipe"))
+	}
+	c.sent++
+	return nil
+}
+
+func (c *testStreamConn) Receive(any) error {
+	if c.incoming == 0 {
+		return io.EOF
+	}
+	c.incoming--
+	return nil
+}
+
+// newTestLogger returns a JSON logger writing to the returned buffer.
+func newTestLogger(level slog.Leveler) (*slog.Logger, *bytes.Buffer) {
+	var buf bytes.Buffer
+	return slog.New(slog.NewJSONHandler(&buf, &slog.HandlerOptions{Level: level})), &buf
+}
+
+// logRecords decodes the JSON log lines written to buf.
+func logRecords(t *testing.T, buf *bytes.Buffer) []map[string]any {
+	t.Helper()
+
+	var records []map[string]any
+	dec := json.NewDecoder(bytes.NewReader(buf.Bytes()))
+	for dec.More() {
+		var record map[string]any
+		if err := dec.Decode(&record); err != nil {
+			t.Fatalf("decode log record: %v", err)
+		}
+		records = append(records, record)
+	}
+	return records
+}
+
+// findRecord returns the first log record with the given message.
+func findRecord(t *testing.T, records []map[string]any, msg string) map[string]any {
+	t.Helper()
+
+	for _, record := range records {
+		if record[slog.MessageKey] == msg {
+			return record
+		}
+	}
+	t.Fatalf("log record %q not found in %v", msg, records)
+	return nil
+}
+
+// callUnary runs a unary request through the interceptor with the given handler.
+func callUnary(t *testing.T, interceptor connect.Interceptor, req connect.AnyRequest, handler connect.UnaryFunc) (connect.AnyResponse, error) {
+	t.Helper()
+	return interceptor.WrapUnary(handler)(context.Background(), req)
+}
+
+func TestWithServiceLoggers(t *testing.T) {
+	defaultLogger, defaultBuf := newTestLogger(slog.LevelInfo)
+	usersLogger, usersBuf := newTestLogger(slog.LevelInfo)
+	ordersLogger, ordersBuf := newTestLogger(slog.LevelInfo)
+
+	interceptor := NewLoggingInterceptor(
+		WithLogger(defaultLogger),
+		WithServiceLoggers(map[string]*slog.Logger{
+			"acme.users.v1.UserService":   usersLogger,
+			"acme.orders.v1.OrderService": ordersLogger,
+		}),
+	)
+
+	handler := func(context.Context, connect.AnyRequest) (connect.AnyResponse, error) {
+		return connect.NewResponse(&struct{}{}), nil
+	}
+	for _, procedure := range []string{
+		"/acme.users.v1.UserService/GetUser",
+		"/acme.orders.v1.OrderService/GetOrder",
+		"/acme.orders.v1.OrderService/ListOrders",
+	} {
+		if _, err := callUnary(t, interceptor, newTestRequest(procedure, &struct{}{}), handler); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	if got := len(logRecords(t, usersBuf)); got != 1 {
+		t.Errorf("expected 1 record for users service, got %d", got)
+	}
+	if got := len(logRecords(t, ordersBuf)); got != 2 {
+		t.Errorf("expected 2 records for orders service, got %d", got)
+	}
+	if got := len(logRecords(t, defaultBuf)); got != 0 {
+		t.Errorf("expected no records on default logger, got %d", got)
+	}
+	if got := findRecord(t, logRecords(t, usersBuf), "request completed")["method"]; got != "GetUser" {
+		t.Errorf("expected method GetUser, got %v", got)
+	}
+}
+
+func TestWithRequestBodyHash(t *testing.T) {
+	logger, buf := newTestLogger(slog.LevelInfo)
+	interceptor := NewLoggingInterceptor(WithLogger(logger), WithRequestBodyHash(true))
+
+	handler := func(context.Context, connect.AnyRequest) (connect.AnyResponse, error) {
+		return connect.NewResponse(&wrapperspb.StringValue{}), nil
+	}
+	for _, value := range []string{"alice", "alice", "bob"} {
+		req := newTestRequest(testProcedure, wrapperspb.String(value))
+		if _, err := callUnary(t, interceptor, req, handler); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	records := logRecords(t, buf)
+	if len(records) != 3 {
+		t.Fatalf("expected 3 records, got %d", len(records))
+	}
+
+	hashes := make([]string, len(records))
+	for idx, record := range records {
+		hash, ok := record["request_hash"].(string)
+		if !ok || len(hash) != 64 {
+			t.Fatalf("expected hex sha256 request_hash, got %v", record["request_hash"])
+		}
+		hashes[idx] = hash
+	}
+	if hashes[0] != hashes[1] {
+		t.Error("expected identical bodies to produce identical hashes")
+	}
+	if hashes[0] == hashes[2] {
+		t.Error("expected different bodies to produce different hashes")
+	}
+	if got := records[0]["request_size"]; got != float64(proto.Size(wrapperspb.String("alice"))) {
+		t.Errorf("expected request_size to match proto size, got %v", got)
+	}
+
+	// Payloads of unknown size are hashed without gaining a request_size
+	buf.Reset()
+	if _, err := callUnary(t, interceptor, newTestRequest(testProcedure, &blobResponse{Name: "alice"}), handler); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	record := findRecord(t, logRecords(t, buf), "request completed")
+	if _, ok := record["request_hash"]; !ok {
+		t.Error("expected request_hash for a struct payload")
+	}
+	if got, ok := record["request_size"]; ok {
+		t.Errorf("expected no request_size for a struct payload, got %v", got)
+	}
+}
+
+func TestShutdown(t *testing.T) {
+	logger, buf := newTestLogger(slog.LevelInfo)
+	interceptor := NewLoggingInterceptor(WithLogger(logger))
+
+	handler := interceptor.WrapStreamingHandler(func(context.Context, connect.StreamingHandlerConn) error {
+		return context.Canceled
+	})
+
+	run := func() map[string]any {
+		buf.Reset()
+		if err := handler(context.Background(), newTestStreamConn(connect.StreamTypeBidi, 0)); err == nil {
+			t.Fatal("expected error")
+		}
+		return findRecord(t, logRecords(t, buf), "stream failed")
+	}
+
+	record := run()
+	if got := record[slog.LevelKey]; got != "WARN" {
+		t.Errorf("expected WARN before shutdown, got %v", got)
+	}
+	if _, ok := record["reason"]; ok {
+		t.Error("unexpected reason before shutdown")
+	}
+
+	interceptor.Shutdown()
+
+	record = run()
+	if got := record[slog.LevelKey]; got != "INFO" {
+		t.Errorf("expected INFO during shutdown, got %v", got)
+	}
+	if got := record["reason"]; got != "shutdown" {
+		t.Errorf("expected reason shutdown, got %v", got)
+	}
+}
+
+func TestWrapStreamingHandler_ClientStreaming(t *testing.T) {
+	logger, buf := newTestLogger(slog.LevelInfo)
+	interceptor := NewLoggingInterceptor(WithLogger(logger))
+
+	handler := interceptor.WrapStreamingHandler(func(_ context.Context, conn connect.StreamingHandlerConn) error {
+		for {
+			if err := conn.Receive(&struct{}{}); err != nil {
+				if errors.Is(err, io.EOF) {
+					break
+				}
+				return err
+			}
+		}
+		return conn.Send(&struct{}{})
+	})
+	if err := handler(context.Background(), newTestStreamConn(connect.StreamTypeClient, 5)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	record := findRecord(t, logRecords(t, buf), "stream completed")
+	messages, _ := record["messages"].(map[string]any)
+	if got := messages["received"]; got != float64(5) {
+		t.Errorf("expected 5 received messages, got %v", got)
+	}
+	if got := messages["sent"]; got != float64(1) {
+		t.Errorf("expected 1 sent message, got %v", got)
+	}
+}
+
+func TestWithLogContentLength(t *testing.T) {
+	tests := []struct {
+		name     string
+		header   string
+		expected any
+	}{
+		{name: "valid header", header: "42", expected: float64(42)},
+		{name: "invalid header", header: "abc"},
+		{name: "missing header"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logger, buf := newTestLogger(slog.LevelInfo)
+			interceptor := NewLoggingInterceptor(WithLogger(logger), WithLogContentLength(true))
+
+			req := newTestRequest(testProcedure, &struct{}{})
+			if tt.header != "" {
+				req.Header().Set("Content-Length", tt.header)
+			}
+			_, _ = callUnary(t, interceptor, req, func(context.Context, connect.AnyRequest) (connect.AnyResponse, error) {
+				return connect.NewResponse(&struct{}{}), nil
+			})
+
+			record := findRecord(t, logRecords(t, buf), "request completed")
+			if got := record["content_length"]; got != tt.expected {
+				t.Errorf("expected content_length %v, got %v", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestDefaultLoggerResolvedLazily(t *testing.T) {
+	previous := slog.Default()
+	defer slog.SetDefault(previous)
+
+	interceptor := NewLoggingInterceptor()
+
+	logger, buf := newTestLogger(slog.LevelInfo)
+	slog.SetDefault(logger)
+
+	req := newTestRequest(testProcedure, &struct{}{})
+	_, _ = callUnary(t, interceptor, req, func(context.Context, connect.AnyRequest) (connect.AnyResponse, error) {
+		return connect.NewResponse(&struct{}{}), nil
+	})
+
+	findRecord(t, logRecords(t, buf), "request completed")
+}
+
+func TestWithLogger_Nil(t *testing.T) {
+	previous := slog.Default()
+	defer slog.SetDefault(previous)
+
+	logger, buf := newTestLogger(slog.LevelInfo)
+	slog.SetDefault(logger)
+
+	interceptor := NewLoggingInterceptor(WithLogger(nil))
+	req := newTestRequest(testProcedure, &struct{}{})
+	_, _ = callUnary(t, interceptor, req, func(context.Context, connect.AnyRequest) (connect.AnyResponse, error) {
+		return connect.NewResponse(&struct{}{}), nil
+	})
+
+	if buf.Len() != 0 {
+		t.Errorf("expected logging to be disabled, got %s", buf)
+	}
+}
+
+func TestWithUniformMessagesGroup(t *testing.T) {
+	tests := []struct {
+		name    string
+		enabled bool
+		err     error
+		sent    any
+	}{
+		{name: "disabled", enabled: false},
+		{name: "success", enabled: true, sent: float64(1)},
+		{name: "failure", enabled: true, err: connect.NewError(connect.CodeNotFound, errors.New("missing")), sent: float64(0)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logger, buf := newTestLogger(slog.LevelInfo)
+			interceptor := NewLoggingInterceptor(WithLogger(logger), WithUniformMessagesGroup(tt.enabled))
+
+			req := newTestRequest(testProcedure, &struct{}{})
+			_, _ = callUnary(t, interceptor, req, func(context.Context, connect.AnyRequest) (connect.AnyResponse, error) {
+				if tt.err != nil {
+					return nil, tt.err
+				}
+				return connect.NewResponse(&struct{}{}), nil
+			})
+
+			record := logRecords(t, buf)[0]
+			messages, ok := record["messages"].(map[string]any)
+			if ok != tt.enabled {
+				t.Fatalf("expected messages group present=%v, got %v", tt.enabled, record["messages"])
+			}
+			if !tt.enabled {
+				return
+			}
+			if got := messages["received"]; got != float64(1) {
+				t.Errorf("expected 1 received, got %v", got)
+			}
+			if got := messages["sent"]; got != tt.sent {
+				t.Errorf("expected %v sent, got %v", tt.sent, got)
+			}
+		})
+	}
+}
+
+func TestWithLogCancelSource(t *testing.T) {
+	expired, cancelExpired := context.WithTimeout(context.Background(), time.Nanosecond)
+	defer cancelExpired()
+	<-expired.Done()
+
+	canceled, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	tests := []struct {
+		name     string
+		ctx      context.Context
+		expected string
+	}{
+		{name: "client canceled", ctx: canceled, expected: "client"},
+		{name: "deadline exceeded", ctx: expired, expected: "deadline"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logger, buf := newTestLogger(slog.LevelInfo)
+			interceptor := NewLoggingInterceptor(WithLogger(logger), WithLogCancelSource(true))
+
+			req := newTestRequest(testProcedure, &struct{}{})
+			_, _ = interceptor.WrapUnary(func(ctx context.Context, _ connect.AnyRequest) (connect.AnyResponse, error) {
+				return nil, ctx.Err()
+			})(tt.ctx, req)
+
+			record := findRecord(t, logRecords(t, buf), "request failed")
+			if got := record["cancel_source"]; got != tt.expected {
+				t.Errorf("expected cancel_source %q, got %v", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestWithAttrsFunc(t *testing.T) {
+	logger, buf := newTestLogger(slog.LevelInfo)
+
+	calls := 0
+	interceptor := NewLoggingInterceptor(WithLogger(logger), WithAttrsFunc(func() []slog.Attr {
+		calls++
+		return []slog.Attr{slog.String("host", "node-1"), slog.String("pod", "api-7")}
+	}))
+
+	req := newTestRequest(testProcedure, &struct{}{})
+	_, _ = callUnary(t, interceptor, req, func(context.Context, connect.AnyRequest) (connect.AnyResponse, error) {
+		return connect.NewResponse(&struct{}{}), nil
+	})
+
+	if calls != 1 {
+		t.Errorf("expected attrs func to be called once per request, got %d", calls)
+	}
+	record := findRecord(t, logRecords(t, buf), "request completed")
+	if record["host"] != "node-1" || record["pod"] != "api-7" {
+		t.Errorf("expected host and pod attributes, got %v", record)
+	}
+}
+
+func TestWithLogErrorMeta(t *testing.T) {
+	logger, buf := newTestLogger(slog.LevelInfo)
+	interceptor := NewLoggingInterceptor(WithLogger(logger), WithLogErrorMeta(true))
+
+	req := newTestRequest(testProcedure, &struct{}{})
+	_, _ = callUnary(t, interceptor, req, func(context.Context, connect.AnyRequest) (connect.AnyResponse, error) {
+		err := connect.NewError(connect.CodeNotFound, errors.New("missing"))
+		err.Meta().Set("X-Resource", "user/42")
+		err.Meta().Set("X-Session-Token", "abc")
+		return nil, err
+	})
+
+	record := findRecord(t, logRecords(t, buf), "request failed")
+	meta, ok := record["error_meta"].(map[string]any)
+	if !ok {
+		t.Fatalf("expected error_meta map, got %v", record["error_meta"])
+	}
+	if got := meta["X-Resource"]; !reflect.DeepEqual(got, []any{"user/42"}) {
+		t.Errorf("expected X-Resource value, got %v", got)
+	}
+	if got := meta["X-Session-Token"]; !reflect.DeepEqual(got, []any{"[REDACTED]"}) {
+		t.Errorf("expected X-Session-Token to be redacted, got %v", got)
+	}
+}
+
+func TestWithQuietAuthErrors(t *testing.T) {
+	tests := []struct {
+		code     connect.Code
+		quiet    bool
+		expected string
+	}{
+		{code: connect.CodeUnauthenticated, quiet: true, expected: "INFO"},
+		{code: connect.CodePermissionDenied, quiet: true, expected: "INFO"},
+		{code: connect.CodeNotFound, quiet: true, expected: "WARN"},
+		{code: connect.CodeUnauthenticated, quiet: false, expected: "ERROR"},
+		{code: connect.CodePermissionDenied, quiet: false, expected: "WARN"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.code.String(), func(t *testing.T) {
+			logger, buf := newTestLogger(slog.LevelInfo)
+			interceptor := NewLoggingInterceptor(WithLogger(logger), WithQuietAuthErrors(tt.quiet))
+
+			req := newTestRequest(testProcedure, &struct{}{})
+			_, _ = callUnary(t, interceptor, req, func(context.Context, connect.AnyRequest) (connect.AnyResponse, error) {
+				return nil, connect.NewError(tt.code, errors.New("denied"))
+			})
+
+			record := findRecord(t, logRecords(t, buf), "request failed")
+			if got := record[slog.LevelKey]; got != tt.expected {
+				t.Errorf("expected level %s, got %v", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestWithSemanticConventions(t *testing.T) {
+	tests := []struct {
+		name   string
+		err    error
+		status float64
+	}{
+		{name: "success", status: 0},
+		{name: "failure", err: connect.NewError(connect.CodeNotFound, errors.New("missing")), status: float64(connect.CodeNotFound)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logger, buf := newTestLogger(slog.LevelInfo)
+			interceptor := NewLoggingInterceptor(WithLogger(logger), WithSemanticConventions(true))
+
+			req := newTestRequest(testProcedure, &struct{}{})
+			_, _ = callUnary(t, interceptor, req, func(context.Context, connect.AnyRequest) (connect.AnyResponse, error) {
+				if tt.err != nil {
+					return nil, tt.err
+				}
+				return connect.NewResponse(&struct{}{}), nil
+			})
+
+			record := logRecords(t, buf)[0]
+			expected := map[string]any{
+				"rpc.system":           "connect_rpc",
+				"rpc.service":          "acme.test.v1.TestService",
+				"rpc.method":           "Call",
+				"rpc.grpc.status_code": tt.status,
+			}
+			for key, want := range expected {
+				if got := record[key]; got != want {
+					t.Errorf("expected %s=%v, got %v", key, want, got)
+				}
+			}
+			for _, key := range []string{"service", "method"} {
+				if _, ok := record[key]; ok {
+					t.Errorf("unexpected native attribute %q", key)
+				}
+			}
+		})
+	}
+}
+
+type routeKey struct{}
+
+func TestWithRouteFromContext(t *testing.T) {
+	logger, buf := newTestLogger(slog.LevelInfo)
+	interceptor := NewLoggingInterceptor(WithLogger(logger), WithRouteFromContext(func(ctx context.Context) string {
+		route, _ := ctx.Value(routeKey{}).(string)
+		return route
+	}))
+
+	next := func(context.Context, connect.AnyRequest) (connect.AnyResponse, error) {
+		return connect.NewResponse(&struct{}{}), nil
+	}
+	ctx := context.WithValue(context.Background(), routeKey{}, "/v2/users")
+	_, _ = interceptor.WrapUnary(next)(ctx, newTestRequest(testProcedure, &struct{}{}))
+	_, _ = callUnary(t, interceptor, newTestRequest(testProcedure, &struct{}{}), next)
+
+	records := logRecords(t, buf)
+	if len(records) != 2 {
+		t.Fatalf("expected 2 records, got %d", len(records))
+	}
+	if got := records[0]["route"]; got != "/v2/users" {
+		t.Errorf("expected route /v2/users, got %v", got)
+	}
+	if got := records[0]["method"]; got != "Call" {
+		t.Errorf("expected method to stay Call, got %v", got)
+	}
+	if _, ok := records[1]["route"]; ok {
+		t.Error("expected no route attribute without a route in context")
+	}
+}
+
+func TestWithDurationRounding(t *testing.T) {
+	logger, buf := newTestLogger(slog.LevelInfo)
+	interceptor := NewLoggingInterceptor(WithLogger(logger), WithDurationRounding(time.Millisecond))
+
+	for range 3 {
+		_, _ = callUnary(t, interceptor, newTestRequest(testProcedure, &struct{}{}), func(context.Context, connect.AnyRequest) (connect.AnyResponse, error) {
+			time.Sleep(1500 * time.Microsecond)
+			return connect.NewResponse(&struct{}{}), nil
+		})
+	}
+
+	for _, record := range logRecords(t, buf) {
+		duration, ok := record["duration"].(float64)
+		if !ok || duration <= 0 {
+			t.Fatalf("expected positive duration, got %v", record["duration"])
+		}
+		if int64(duration)%int64(time.Millisecond) != 0 {
+			t.Errorf("expected duration rounded to milliseconds, got %v", time.Duration(duration))
+		}
+	}
+}
+
+func TestWithEnvironment(t *testing.T) {
+	logger, buf := newTestLogger(slog.LevelDebug)
+	interceptor := NewLoggingInterceptor(WithLogger(logger), WithEnvironment("staging"))
+
+	_, _ = callUnary(t, interceptor, newTestRequest(testProcedure, &struct{}{}), func(context.Context, connect.AnyRequest) (connect.AnyResponse, error) {
+		return connect.NewResponse(&struct{}{}), nil
+	})
+
+	for _, record := range logRecords(t, buf) {
+		if got := record["env"]; got != "staging" {
+			t.Errorf("%v: expected env staging, got %v", record[slog.MessageKey], got)
+		}
+	}
+}
+
+func TestOptions(t *testing.T) {
+	interceptor := NewLoggingInterceptor(
+		WithEnvironment("prod"),
+		WithSmartSampling(SamplingConfig{FastSampleRate: 1}),
+		WithServiceLoggers(map[string]*slog.Logger{"acme.test.v1.TestService": slog.Default()}),
+	)
+
+	options := interceptor.Options()
+	if !slices.Equal(options.RedactHeaders, []string{"authorization", "token"}) {
+		t.Errorf("expected default redact headers, got %v", options.RedactHeaders)
+	}
+	if options.Environment != "prod" {
+		t.Errorf("expected environment prod, got %q", options.Environment)
+	}
+
+	// Mutating the copy must not leak into the interceptor
+	options.RedactHeaders[0] = "x-changed"
+	options.Environment = "dev"
+	options.Sampling.FastSampleRate = 0
+	delete(options.ServiceLoggers, "acme.test.v1.TestService")
+	got := interceptor.Options()
+	if got.RedactHeaders[0] != "authorization" || got.Environment != "prod" {
+		t.Errorf("expected options to be unchanged, got %v", got)
+	}
+	if got.Sampling.FastSampleRate != 1 || len(got.ServiceLoggers) != 1 {
+		t.Errorf("expected sampling and service loggers to be unchanged, got %v", got)
+	}
+
+	// The interceptor itself keeps logging
+	logger, buf := newTestLogger(slog.LevelInfo)
+	interceptor = NewLoggingInterceptor(WithLogger(logger), WithSmartSampling(SamplingConfig{FastSampleRate: 1}))
+	interceptor.Options().Sampling.FastSampleRate = 0
+	_, _ = callUnary(t, interceptor, newTestRequest(testProcedure, &struct{}{}), func(context.Context, connect.AnyRequest) (connect.AnyResponse, error) {
+		return connect.NewResponse(&struct{}{}), nil
+	})
+	findRecord(t, logRecords(t, buf), "request completed")
+}
+
+func TestWithHooks(t *testing.T) {
+	errFailed := connect.NewError(connect.CodeInternal, errors.New("failed"))
+
+	var pre, post []string
+	interceptor := NewLoggingInterceptor(
+		WithLogger(nil),
+		WithPreHook(func(_ context.Context, spec connect.Spec, peer connect.Peer) {
+			pre = append(pre, spec.Procedure+" "+peer.Protocol)
+		}),
+		WithPostHook(func(_ context.Context, spec connect.Spec, _ connect.Peer, err error, duration time.Duration) {
+			if duration <= 0 {
+				t.Errorf("expected positive duration, got %v", duration)
+			}
+			post = append(post, spec.Procedure+" "+connect.CodeOf(err).String())
+		}),
+	)
+
+	_, _ = callUnary(t, interceptor, newTestRequest(testProcedure, &struct{}{}), func(context.Context, connect.AnyRequest) (connect.AnyResponse, error) {
+		return nil, errFailed
+	})
+	handler := interceptor.WrapStreamingHandler(func(context.Context, connect.StreamingHandlerConn) error {
+		return errFailed
+	})
+	_ = handler(context.Background(), newTestStreamConn(connect.StreamTypeBidi, 0))
+
+	expectedPre := []string{testProcedure + " connect", testProcedure + " grpc"}
+	if !slices.Equal(pre, expectedPre) {
+		t.Errorf("expected pre hook calls %v, got %v", expectedPre, pre)
+	}
+	expectedPost := []string{testProcedure + " internal", testProcedure + " internal"}
+	if !slices.Equal(post, expectedPost) {
+		t.Errorf("expected post hook calls %v, got %v", expectedPost, post)
+	}
+}
+
+type tenantKey struct{}
+
+func TestWithTenantFromContext(t *testing.T) {
+	logger, buf := newTestLogger(slog.LevelDebug)
+	interceptor := NewLoggingInterceptor(WithLogger(logger), WithTenantFromContext(func(ctx context.Context) string {
+		tenant, _ := ctx.Value(tenantKey{}).(string)
+		return tenant
+	}))
+
+	ctx := context.WithValue(context.Background(), tenantKey{}, "acme")
+	_, _ = interceptor.WrapUnary(func(context.Context, connect.AnyRequest) (connect.AnyResponse, error) {
+		return connect.NewResponse(&struct{}{}), nil
+	})(ctx, newTestRequest(testProcedure, &struct{}{}))
+
+	for _, record := range logRecords(t, buf) {
+		if got := record["tenant"]; got != "acme" {
+			t.Errorf("%v: expected tenant acme, got %v", record[slog.MessageKey], got)
+		}
+	}
+}
+
+func TestWithLogTransportDetails(t *testing.T) {
+	logger, buf := newTestLogger(slog.LevelInfo)
+	interceptor := NewLoggingInterceptor(WithLogger(logger), WithLogTransportDetails(true))
+
+	req := newTestRequest(testProcedure, &struct{}{})
+	req.method = http.MethodGet
+	_, _ = callUnary(t, interceptor, req, func(context.Context, connect.AnyRequest) (connect.AnyResponse, error) {
+		return connect.NewResponse(&struct{}{}), nil
+	})
+	handler := interceptor.WrapStreamingHandler(func(context.Context, connect.StreamingHandlerConn) error {
+		return nil
+	})
+	_ = handler(context.Background(), newTestStreamConn(connect.StreamTypeServer, 0))
+
+	records := logRecords(t, buf)
+	expected := map[string]any{"method": "GET", "stream_type": "unary"}
+	if got := findRecord(t, records, "request completed")["transport"]; !reflect.DeepEqual(got, expected) {
+		t.Errorf("expected transport %v, got %v", expected, got)
+	}
+	expected = map[string]any{"method": "POST", "stream_type": "server"}
+	if got := findRecord(t, records, "stream completed")["transport"]; !reflect.DeepEqual(got, expected) {
+		t.Errorf("expected transport %v, got %v", expected, got)
+	}
+}
+
+func TestEmptyPeer(t *testing.T) {
+	logger, buf := newTestLogger(slog.LevelDebug)
+	interceptor := NewLoggingInterceptor(WithLogger(logger), WithSemanticConventions(true))
+
+	req := newTestRequest(testProcedure, &struct{}{})
+	req.peer = connect.Peer{}
+	_, _ = callUnary(t, interceptor, req, func(context.Context, connect.AnyRequest) (connect.AnyResponse, error) {
+		return connect.NewResponse(&struct{}{}), nil
+	})
+
+	for _, record := range logRecords(t, buf) {
+		for _, key := range []string{"addr", "protocol", "rpc.system"} {
+			if value, ok := record[key]; ok {
+				t.Errorf("%v: expected no %s attribute, got %v", record[slog.MessageKey], key, value)
+			}
+		}
+	}
+}
+
+func TestProtocolAttr(t *testing.T) {
+	tests := []struct {
+		protocol string
+		expected string
+	}{
+		{protocol: connect.ProtocolConnect, expected: "connect"},
+		{protocol: connect.ProtocolGRPC, expected: "grpc"},
+		{protocol: connect.ProtocolGRPCWeb, expected: "grpc_web"},
+	}
+
+	for _, tt := range tests {
+		logger, buf := newTestLogger(slog.LevelInfo)
+		interceptor := NewLoggingInterceptor(WithLogger(logger))
+
+		req := newTestRequest(testProcedure, &struct{}{})
+		req.peer.Protocol = tt.protocol
+		_, _ = callUnary(t, interceptor, req, func(context.Context, connect.AnyRequest) (connect.AnyResponse, error) {
+			return connect.NewResponse(&struct{}{}), nil
+		})
+
+		if got := findRecord(t, logRecords(t, buf), "request completed")["protocol"]; got != tt.expected {
+			t.Errorf("%s: expected protocol %q, got %v", tt.protocol, tt.expected, got)
+		}
+	}
+}
+
+func TestWithLogDeadline(t *testing.T) {
+	logger, buf := newTestLogger(slog.LevelDebug)
+	interceptor := NewLoggingInterceptor(WithLogger(logger), WithLogDeadline(true))
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	_, _ = interceptor.WrapUnary(func(context.Context, connect.AnyRequest) (connect.AnyResponse, error) {
+		return connect.NewResponse(&struct{}{}), nil
+	})(ctx, newTestRequest(testProcedure, &struct{}{}))
+	_ = interceptor.WrapStreamingHandler(func(context.Context, connect.StreamingHandlerConn) error {
+		return nil
+	})(ctx, newTestStreamConn(connect.StreamTypeServer, 0))
+
+	records := logRecords(t, buf)
+	for _, msg := range []string{"request started", "stream started"} {
+		deadlineIn, ok := findRecord(t, records, msg)["deadline_in"].(float64)
+		if !ok || deadlineIn <= 0 || time.Duration(deadlineIn) > time.Minute {
+			t.Errorf("%s: expected deadline_in within a minute, got %v", msg, deadlineIn)
+		}
+	}
+}
+
+func TestWithErrorLogger(t *testing.T) {
+	logger, buf := newTestLogger(slog.LevelInfo)
+	errLogger, errBuf := newTestLogger(slog.LevelInfo)
+	interceptor := NewLoggingInterceptor(WithLogger(logger), WithErrorLogger(errLogger), WithEnvironment("prod"))
+
+	for _, fail := range []bool{false, true} {
+		_, _ = callUnary(t, interceptor, newTestRequest(testProcedure, &struct{}{}), func(context.Context, connect.AnyRequest) (connect.AnyResponse, error) {
+			if fail {
+				return nil, connect.NewError(connect.CodeInternal, errors.New("boom"))
+			}
+			return connect.NewResponse(&struct{}{}), nil
+		})
+	}
+
+	records := logRecords(t, buf)
+	if len(records) != 1 || records[0][slog.MessageKey] != "request completed" {
+		t.Errorf("expected only the success on the main logger, got %v", records)
+	}
+	errRecords := logRecords(t, errBuf)
+	if len(errRecords) != 1 || errRecords[0][slog.MessageKey] != "request failed" {
+		t.Fatalf("expected only the failure on the error logger, got %v", errRecords)
+	}
+	if errRecords[0]["env"] != "prod" || errRecords[0]["method"] != "Call" {
+		t.Errorf("expected request attributes on the error logger, got %v", errRecords[0])
+	}
+}
+
+func TestWithSequenceNumbers(t *testing.T) {
+	logger, buf := newTestLogger(slog.LevelInfo)
+	interceptor := NewLoggingInterceptor(WithLogger(logger), WithSequenceNumbers(true))
+
+	for n := range 3 {
+		_, _ = callUnary(t, interceptor, newTestRequest(testProcedure, &struct{}{}), func(context.Context, connect.AnyRequest) (connect.AnyResponse, error) {
+			if n == 1 {
+				return nil, connect.NewError(connect.CodeInternal, errors.New("boom"))
+			}
+			return connect.NewResponse(&struct{}{}), nil
+		})
+	}
+	_ = interceptor.WrapStreamingHandler(func(context.Context, connect.StreamingHandlerConn) error {
+		return nil
+	})(context.Background(), newTestStreamConn(connect.StreamTypeServer, 0))
+
+	records := logRecords(t, buf)
+	if len(records) != 4 {
+		t.Fatalf("expected 4 records, got %d", len(records))
+	}
+	for n, record := range records {
+		if got := record["seq"]; got != float64(n+1) {
+			t.Errorf("record %d: expected seq %d, got %v", n, n+1, got)
+		}
+	}
+}
+
+func TestRequestAttrs(t *testing.T) {
+	logger, buf := newTestLogger(slog.LevelInfo)
+	interceptor := NewLoggingInterceptor(WithLogger(logger))
+
+	req := newTestRequest(testProcedure, &struct{}{})
+	_, _ = callUnary(t, interceptor, req, func(context.Context, connect.AnyRequest) (connect.AnyResponse, error) {
+		return connect.NewResponse(&struct{}{}), nil
+	})
+	record := findRecord(t, logRecords(t, buf), "request completed")
+
+	attrs := RequestAttrs(req.Spec(), req.Peer())
+	if len(attrs) != 4 {
+		t.Fatalf("expected service, method, protocol and addr, got %v", attrs)
+	}
+	for _, attr := range attrs {
+		if got := record[attr.Key]; got != attr.Value.String() {
+			t.Errorf("%s: interceptor logged %v, RequestAttrs returned %v", attr.Key, got, attr.Value)
+		}
+	}
+}
+
+func TestWithSummaryMessage(t *testing.T) {
+	logger, buf := newTestLogger(slog.LevelInfo)
+	interceptor := NewLoggingInterceptor(WithLogger(logger), WithSummaryMessage(true), WithDurationRounding(time.Hour))
+
+	req := newTestRequest(testProcedure, &struct{}{})
+	req.method = http.MethodPost
+	_, _ = callUnary(t, interceptor, req, func(context.Context, connect.AnyRequest) (connect.AnyResponse, error) {
+		return connect.NewResponse(&struct{}{}), nil
+	})
+	_ = interceptor.WrapStreamingHandler(func(context.Context, connect.StreamingHandlerConn) error {
+		return connect.NewError(connect.CodeNotFound, nil)
+	})(context.Background(), newTestStreamConn(connect.StreamTypeServer, 0))
+
+	records := logRecords(t, buf)
+	expected := []string{"POST TestService/Call ok 0s", "POST TestService/Call not_found 0s"}
+	for n, record := range records {
+		if got := record[slog.MessageKey]; got != expected[n] {
+			t.Errorf("expected message %q, got %v", expected[n], got)
+		}
+		if record["service"] != "acme.test.v1.TestService" {
+			t.Errorf("expected structured attributes to be kept, got %v", record)
+		}
+	}
+}
+
+func TestWithSpanStyleLogging(t *testing.T) {
+	ok := func(context.Context, connect.AnyRequest) (connect.AnyResponse, error) {
+		return connect.NewResponse(&struct{}{}), nil
+	}
+
+	t.Run("paired records", func(t *testing.T) {
+		logger, buf := newTestLogger(slog.LevelDebug)
+		interceptor := NewLoggingInterceptor(WithLogger(logger), WithSpanStyleLogging(true))
+
+		_, _ = callUnary(t, interceptor, newTestRequest(testProcedure, &struct{}{}), ok)
+
+		records := logRecords(t, buf)
+		start, end := findRecord(t, records, "rpc.start"), findRecord(t, records, "rpc.end")
+		spanID, _ := start["span_id"].(string)
+		if len(spanID) != 16 || end["span_id"] != spanID {
+			t.Errorf("expected a matching span id, got %v and %v", start["span_id"], end["span_id"])
+		}
+		if _, ok := start["request"]; !ok {
+			t.Error("expected the start record to carry the request")
+		}
+		if _, ok := end["response"]; !ok {
+			t.Error("expected the end record to carry the response")
+		}
+		if _, ok := end["duration"]; !ok {
+			t.Error("expected the end record to carry the duration")
+		}
+	})
+
+	t.Run("bodies need debug", func(t *testing.T) {
+		logger, buf := newTestLogger(slog.LevelInfo)
+		interceptor := NewLoggingInterceptor(WithLogger(logger), WithSpanStyleLogging(true))
+
+		_, _ = callUnary(t, interceptor, newTestRequest(testProcedure, &struct{}{}), ok)
+
+		records := logRecords(t, buf)
+		if _, ok := findRecord(t, records, "rpc.start")["request"]; ok {
+			t.Error("expected no request body at info level")
+		}
+		if _, ok := findRecord(t, records, "rpc.end")["response"]; ok {
+			t.Error("expected no response body at info level")
+		}
+	})
+
+	t.Run("sampled out", func(t *testing.T) {
+		logger, buf := newTestLogger(slog.LevelInfo)
+		interceptor := NewLoggingInterceptor(WithLogger(logger), WithSpanStyleLogging(true), WithSmartSampling(SamplingConfig{FastSampleRate: 0}))
+
+		_, _ = callUnary(t, interceptor, newTestRequest(testProcedure, &struct{}{}), ok)
+
+		records := logRecords(t, buf)
+		findRecord(t, records, "rpc.start")
+		findRecord(t, records, "rpc.end")
+	})
+
+	t.Run("empty stream", func(t *testing.T) {
+		logger, buf := newTestLogger(slog.LevelInfo)
+		interceptor := NewLoggingInterceptor(WithLogger(logger), WithSpanStyleLogging(true), WithSkipEmptyStreams(true))
+
+		_ = interceptor.WrapStreamingHandler(func(context.Context, connect.StreamingHandlerConn) error {
+			return nil
+		})(context.Background(), newTestStreamConn(connect.StreamTypeServer, 0))
+
+		records := logRecords(t, buf)
+		findRecord(t, records, "rpc.start")
+		findRecord(t, records, "rpc.end")
+	})
+}
+
+func TestWithCombinedRPCAttr(t *testing.T) {
+	logger, buf := newTestLogger(slog.LevelInfo)
+	interceptor := NewLoggingInterceptor(WithLogger(logger), WithCombinedRPCAttr(true))
+
+	_, _ = callUnary(t, interceptor, newTestRequest(testProcedure, &struct{}{}), func(context.Context, connect.AnyRequest) (connect.AnyResponse, error) {
+		return connect.NewResponse(&struct{}{}), nil
+	})
+
+	record := findRecord(t, logRecords(t, buf), "request completed")
+	if got := record["rpc"]; got != "TestService/Call" {
+		t.Errorf("expected rpc TestService/Call, got %v", got)
+	}
+	if record["service"] != "acme.test.v1.TestService" || record["method"] != "Call" {
+		t.Errorf("expected separate service and method to be kept, got %v", record)
+	}
+}
+
+func TestWithLargeRequestThreshold(t *testing.T) {
+	tests := []struct {
+		name     string
+		payload  string
+		expected string
+		large    bool
+	}{
+		{name: "small", payload: "ok", expected: "INFO"},
+		{name: "large", payload: strings.Repeat("x", 128), expected: "WARN", large: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logger, buf := newTestLogger(slog.LevelInfo)
+			interceptor := NewLoggingInterceptor(WithLogger(logger), WithLargeRequestThreshold(64))
+
+			_, _ = callUnary(t, interceptor, newTestRequest(testProcedure, wrapperspb.String(tt.payload)), func(context.Context, connect.AnyRequest) (connect.AnyResponse, error) {
+				return connect.NewResponse(&struct{}{}), nil
+			})
+
+			record := findRecord(t, logRecords(t, buf), "request completed")
+			if got := record[slog.LevelKey]; got != tt.expected {
+				t.Errorf("expected level %s, got %v", tt.expected, got)
+			}
+			if _, ok := record["large_request"]; ok != tt.large {
+				t.Errorf("expected large_request present=%v, got %v", tt.large, record["large_request"])
+			}
+		})
+	}
+}
+
+func TestWithContext(t *testing.T) {
+	tests := []struct {
+		name string
+		stop func(cancel context.CancelFunc, interceptor *LoggingInterceptor)
+	}{
+		{name: "context canceled", stop: func(cancel context.CancelFunc, _ *LoggingInterceptor) { cancel() }},
+		{name: "close", stop: func(_ context.CancelFunc, interceptor *LoggingInterceptor) { _ = interceptor.Close() }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			interceptor := NewLoggingInterceptor(WithLogger(nil), WithContext(ctx))
+
+			exited := make(chan struct{}, 2)
+			for range 2 {
+				interceptor.goBackground(func(ctx context.Context) {
+					<-ctx.Done()
+					exited <- struct{}{}
+				})
+			}
+
+			tt.stop(cancel, interceptor)
+			for range 2 {
+				select {
+				case <-exited:
+				case <-time.After(time.Second):
+					t.Fatal("background goroutine did not exit")
+				}
+			}
+			_ = interceptor.Close() // idempotent
+		})
+	}
+}
+
+type cacheKey struct{}
+
+func TestWithCacheHitFromContext(t *testing.T) {
+	logger, buf := newTestLogger(slog.LevelInfo)
+	interceptor := NewLoggingInterceptor(WithLogger(logger), WithCacheHitFromContext(func(ctx context.Context) (bool, bool) {
+		hit, ok := ctx.Value(cacheKey{}).(*bool)
+		if !ok {
+			return false, false
+		}
+		return *hit, true
+	}))
+
+	handler := interceptor.WrapUnary(func(ctx context.Context, _ connect.AnyRequest) (connect.AnyResponse, error) {
+		if hit, ok := ctx.Value(cacheKey{}).(*bool); ok {
+			*hit = true // served from cache
+		}
+		return connect.NewResponse(&struct{}{}), nil
+	})
+	_, _ = handler(context.WithValue(context.Background(), cacheKey{}, new(bool)), newTestRequest(testProcedure, &struct{}{}))
+	_, _ = handler(context.Background(), newTestRequest(testProcedure, &struct{}{}))
+
+	records := logRecords(t, buf)
+	if got := records[0]["cache_hit"]; got != true {
+		t.Errorf("expected cache_hit true, got %v", got)
+	}
+	if _, ok := records[1]["cache_hit"]; ok {
+		t.Error("expected no cache_hit without a signal")
+	}
+}
+
+func TestWithLogIdempotency(t *testing.T) {
+	tests := []struct {
+		level    connect.IdempotencyLevel
+		expected any
+	}{
+		{level: connect.IdempotencyNoSideEffects, expected: "no_side_effects"},
+		{level: connect.IdempotencyIdempotent, expected: "idempotent"},
+		{level: connect.IdempotencyUnknown},
+	}
+
+	for _, tt := range tests {
+		logger, buf := newTestLogger(slog.LevelInfo)
+		interceptor := NewLoggingInterceptor(WithLogger(logger), WithLogIdempotency(true))
+
+		req := newTestRequest(testProcedure, &struct{}{})
+		req.spec.IdempotencyLevel = tt.level
+		_, _ = callUnary(t, interceptor, req, func(context.Context, connect.AnyRequest) (connect.AnyResponse, error) {
+			return connect.NewResponse(&struct{}{}), nil
+		})
+
+		if got := findRecord(t, logRecords(t, buf), "request completed")["idempotency_level"]; got != tt.expected {
+			t.Errorf("%v: expected idempotency_level %v, got %v", tt.level, tt.expected, got)
+		}
+	}
+}
+
+func TestWithPhaseTiming(t *testing.T) {
+	logger, buf := newTestLogger(slog.LevelInfo)
+	interceptor := NewLoggingInterceptor(WithLogger(logger), WithPhaseTiming(true))
+
+	_, _ = callUnary(t, interceptor, newTestRequest(testProcedure, &struct{}{}), func(context.Context, connect.AnyRequest) (connect.AnyResponse, error) {
+		time.Sleep(5 * time.Millisecond)
+		return connect.NewResponse(&struct{}{}), nil
+	})
+
+	record := findRecord(t, logRecords(t, buf), "request completed")
+	duration, ok := record["duration"].(float64)
+	if !ok {
+		t.Fatalf("expected duration, got %v", record["duration"])
+	}
+	handlerDuration, ok := record["handler_duration"].(float64)
+	if !ok {
+		t.Fatalf("expected handler_duration, got %v", record["handler_duration"])
+	}
+	if handlerDuration < float64(5*time.Millisecond) || handlerDuration > duration {
+		t.Errorf("expected 5ms <= handler_duration <= duration (%v), got %v", duration, handlerDuration)
+	}
+}
+
+func TestWithConcurrencyLogging(t *testing.T) {
+	logger, buf := newTestLogger(slog.LevelInfo)
+	interceptor := NewLoggingInterceptor(WithLogger(logger), WithConcurrencyLogging(true))
+
+	const calls = 8
+	var entered, done sync.WaitGroup
+	entered.Add(calls)
+	release := make(chan struct{})
+	handler := interceptor.WrapUnary(func(context.Context, connect.AnyRequest) (connect.AnyResponse, error) {
+		entered.Done()
+		<-release
+		return connect.NewResponse(&struct{}{}), nil
+	})
+
+	done.Add(calls)
+	for range calls {
+		go func() {
+			defer done.Done()
+			_, _ = handler(context.Background(), newTestRequest(testProcedure, &struct{}{}))
+		}()
+	}
+	entered.Wait()
+	close(release)
+	done.Wait()
+
+	// The first call to complete sees all calls still in flight
+	var highest float64
+	for _, record := range logRecords(t, buf) {
+		inFlight, _ := record["in_flight"].(float64)
+		if inFlight < 1 || inFlight > calls {
+			t.Errorf("expected in_flight between 1 and %d, got %v", calls, record["in_flight"])
+		}
+		highest = max(highest, inFlight)
+	}
+	if highest != calls {
+		t.Errorf("expected in_flight to reach %d, got %v", calls, highest)
+	}
+	if got := interceptor.inFlight.Load(); got != 0 {
+		t.Errorf("expected no calls in flight after completion, got %d", got)
+	}
+}
+
+func TestWithWriter(t *testing.T) {
+	handler := func(context.Context, connect.AnyRequest) (connect.AnyResponse, error) {
+		return connect.NewResponse(&struct{}{}), nil
+	}
+
+	var jsonBuf bytes.Buffer
+	_, _ = callUnary(t, NewLoggingInterceptor(WithWriter(&jsonBuf, "json")), newTestRequest(testProcedure, &struct{}{}), handler)
+	if got := findRecord(t, logRecords(t, &jsonBuf), "request completed")["method"]; got != "Call" {
+		t.Errorf("expected a JSON record for method Call, got %v", got)
+	}
+
+	var textBuf bytes.Buffer
+	_, _ = callUnary(t, NewLoggingInterceptor(WithWriter(&textBuf, "text")), newTestRequest(testProcedure, &struct{}{}), handler)
+	if got := textBuf.String(); !strings.Contains(got, `msg="request completed"`) || !strings.Contains(got, "method=Call") {
+		t.Errorf("expected a text record for method Call, got %q", got)
+	}
+}
+
+func TestWithSlowThreshold(t *testing.T) {
+	logger, buf := newTestLogger(slog.LevelInfo)
+	interceptor := NewLoggingInterceptor(WithLogger(logger), WithSlowThreshold(time.Millisecond), WithSlowStreamThreshold(time.Hour))
+
+	_, _ = callUnary(t, interceptor, newTestRequest(testProcedure, &struct{}{}), func(context.Context, connect.AnyRequest) (connect.AnyResponse, error) {
+		time.Sleep(5 * time.Millisecond)
+		return connect.NewResponse(&struct{}{}), nil
+	})
+
+	if got := findRecord(t, logRecords(t, buf), "request completed")["slow"]; got != true {
+		t.Errorf("expected slow unary call to be flagged, got %v", got)
+	}
+}
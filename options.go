@@ -1,23 +1,162 @@
 package connectlog
 
 import (
+	"context"
+	"io"
 	"log/slog"
+	"maps"
+	"regexp"
+	"slices"
+	"time"
+
+	"connectrpc.com/connect"
 )
 
 type Options struct {
 	Logger        *slog.Logger
 	RedactHeaders []string
 	ContextLogFn  ContextLogFunc
+	LogBodyShape  bool
+
+	MaxHeaderValueLength    int
+	ServiceLoggers          map[string]*slog.Logger
+	RequestBodyHash         bool
+	PanicStackDepth         int
+	LogPanics               bool
+	FlatSchema              bool
+	LogContentLength        bool
+	Sampling                *SamplingConfig
+	UniformMessagesGroup    bool
+	LogCancelSource         bool
+	AttrsFn                 func() []slog.Attr
+	LogErrorMeta            bool
+	QuietAuthErrors         bool
+	RequestIDHeader         string
+	SlowMessageThreshold    time.Duration
+	SlowMessageSample       bool
+	SkipEmptyStreams        bool
+	RedactAttrs             []string
+	LogMessageTypes         bool
+	SemanticConventions     bool
+	RecoverCode             connect.Code
+	LogAcceptEncoding       bool
+	SamplingKey             func(context.Context) string
+	ByteCounter             ByteCounterFunc
+	LogAuthScheme           bool
+	OnError                 func(context.Context, ErrorInfo)
+	RouteFn                 func(context.Context) string
+	DurationRounding        time.Duration
+	Environment             string
+	StreamMessageBatch      int
+	ResponseTransformer     func(any) any
+	PreHook                 PreHookFunc
+	PostHook                PostHookFunc
+	TenantFn                func(context.Context) string
+	DebugTrigger            func(context.Context) bool
+	NeverRedact             []string
+	LogTransportDetails     bool
+	MaxBodyFields           int
+	SampleRates             map[connect.Code]float64
+	DefaultSampleRate       float64
+	LogDeadline             bool
+	ErrorLogger             *slog.Logger
+	SequenceNumbers         bool
+	BodyAsJSON              bool
+	CallerInfo              bool
+	SummaryMessage          bool
+	BodyLoggingCodes        []connect.Code
+	CombinedRPCAttr         bool
+	RedactCookies           []string
+	LargeRequestThreshold   int
+	GenericErrorMessages    bool
+	Context                 context.Context
+	HashLongHeaders         bool
+	CacheHitFn              func(context.Context) (hit, ok bool)
+	LogTLSPeer              bool
+	LogIdempotency          bool
+	StreamSummaryInterval   time.Duration
+	BodyAsYAML              bool
+	StreamMessageSizes      bool
+	ErrorSampling           map[connect.Code]float64
+	LogCodecSizes           bool
+	PhaseTiming             bool
+	RedactTokenLikeValues   bool
+	ConcurrencyLogging      bool
+	LazyBodies              bool
+	ProcedureAliases        map[string]string
+	Redactor                Redactor
+	AggregationWindow       time.Duration
+	LogCodec                bool
+	ContextValues           map[any]string
+	SlowThreshold           time.Duration
+	SlowStreamThreshold     time.Duration
+	DetectSizeMismatch      bool
+	RedactBodyValuePatterns []*regexp.Regexp
+	SchemaVersion           string
+	StreamIdleWarning       time.Duration
+	SpanStyleLogging        bool
 }
 
 type Option func(*Options)
 
+// newOptions applies opts to the default options.
+func newOptions(opts []Option) Options {
+	options := Options{
+		RedactHeaders:      []string{"authorization", "token"},
+		StreamMessageSizes: true,
+	}
+	for _, opt := range opts {
+		opt(&options)
+	}
+	return options
+}
+
+// clone returns a copy of o that shares no pointers, slices or maps with
+// it.
+func (o Options) clone() Options {
+	if o.Sampling != nil {
+		cfg := *o.Sampling
+		o.Sampling = &cfg
+	}
+	o.RedactHeaders = slices.Clone(o.RedactHeaders)
+	o.RedactAttrs = slices.Clone(o.RedactAttrs)
+	o.NeverRedact = slices.Clone(o.NeverRedact)
+	o.RedactBodyValuePatterns = slices.Clone(o.RedactBodyValuePatterns)
+	o.SampleRates = maps.Clone(o.SampleRates)
+	o.ErrorSampling = maps.Clone(o.ErrorSampling)
+	o.BodyLoggingCodes = slices.Clone(o.BodyLoggingCodes)
+	o.RedactCookies = slices.Clone(o.RedactCookies)
+	o.ServiceLoggers = maps.Clone(o.ServiceLoggers)
+	o.ProcedureAliases = maps.Clone(o.ProcedureAliases)
+	o.ContextValues = maps.Clone(o.ContextValues)
+	return o
+}
+
+// WithLogger sets the logger used by the interceptor. A nil logger disables
+// logging. Without this option slog.Default() is resolved on every request,
+// so later calls to slog.SetDefault take effect.
 func WithLogger(logger *slog.Logger) Option {
 	return func(o *Options) {
+		if logger == nil {
+			logger = slog.New(slog.DiscardHandler)
+		}
 		o.Logger = logger
 	}
 }
 
+// WithWriter logs to w with a slog built-in handler, as a shortcut for
+// WithLogger: format "text" uses slog.TextHandler, any other value (e.g.
+// "json") slog.JSONHandler. Records below Info are dropped.
+func WithWriter(w io.Writer, format string) Option {
+	var handler slog.Handler
+	if format == "text" {
+		handler = slog.NewTextHandler(w, nil)
+	} else {
+		handler = slog.NewJSONHandler(w, nil)
+	}
+	return WithLogger(slog.New(handler))
+}
+
 func WithRedactHeaders(headers []string) Option {
 	return func(o *Options) {
 		o.RedactHeaders = headers
@@ -29,3 +168,734 @@ func WithContextLogFn(fn ContextLogFunc) Option {
 		o.ContextLogFn = fn
 	}
 }
+
+// WithLogBodyShape logs the names of the populated body fields instead of
+// their values for proto and JSON payloads.
+func WithLogBodyShape(enabled bool) Option {
+	return func(o *Options) {
+		o.LogBodyShape = enabled
+	}
+}
+
+// WithMaxHeaderValueLength truncates logged header values longer than n
+// bytes. Redacted values are not affected; n <= 0 disables truncation.
+func WithMaxHeaderValueLength(n int) Option {
+	return func(o *Options) {
+		o.MaxHeaderValueLength = n
+	}
+}
+
+// WithServiceLoggers routes the logs of the given services (keyed by the
+// fully-qualified service name, e.g. "acme.foo.v1.FooService") to dedicated
+// loggers. Other services use the default logger.
+func WithServiceLoggers(loggers map[string]*slog.Logger) Option {
+	return func(o *Options) {
+		o.ServiceLoggers = maps.Clone(loggers)
+	}
+}
+
+// WithRequestBodyHash adds a SHA-256 hash of the marshaled request body as
+// request_hash to the completion log, allowing identical requests to be
+// matched without logging their content. The interceptor only sees decoded
+// messages, so every request is marshaled once more for the hash:
+// deterministically for proto messages and as JSON for other values.
+func WithRequestBodyHash(enabled bool) Option {
+	return func(o *Options) {
+		o.RequestBodyHash = enabled
+	}
+}
+
+// WithPanicStackDepth limits the number of stack frames logged for a
+// recovered panic. A value of 0 logs the full stack.
+func WithPanicStackDepth(n int) Option {
+	return func(o *Options) {
+		o.PanicStackDepth = n
+	}
+}
+
+// WithLogPanics logs panics raised by handlers with their stack before
+// re-raising them. Panics recovered with WithRecoverToError are always
+// logged.
+func WithLogPanics(enabled bool) Option {
+	return func(o *Options) {
+		o.LogPanics = enabled
+	}
+}
+
+// WithFlatSchema flattens grouped attributes into dotted keys
+// (messages.sent, error.code) for log consumers that can't parse nested
+// objects.
+func WithFlatSchema(enabled bool) Option {
+	return func(o *Options) {
+		o.FlatSchema = enabled
+	}
+}
+
+// WithLogContentLength adds the request Content-Length header, when present,
+// as content_length to the completion log. Unlike request_size, it reflects
+// the size of the request on the wire.
+func WithLogContentLength(enabled bool) Option {
+	return func(o *Options) {
+		o.LogContentLength = enabled
+	}
+}
+
+// WithSmartSampling limits which completed calls are logged according to
+// cfg. See SamplingConfig for the precedence of its rules.
+func WithSmartSampling(cfg SamplingConfig) Option {
+	return func(o *Options) {
+		o.Sampling = &cfg
+	}
+}
+
+// WithUniformMessagesGroup adds a messages group to unary completion logs
+// (one received request, one sent response on success) so unary and
+// streaming records share the same schema.
+func WithUniformMessagesGroup(enabled bool) Option {
+	return func(o *Options) {
+		o.UniformMessagesGroup = enabled
+	}
+}
+
+// WithLogCancelSource adds cancel_source ("client" or "deadline") to logs of
+// calls ending with Canceled or DeadlineExceeded, telling a client hang-up
+// apart from an expired deadline.
+func WithLogCancelSource(enabled bool) Option {
+	return func(o *Options) {
+		o.LogCancelSource = enabled
+	}
+}
+
+// WithAttrsFunc adds the attributes returned by fn to every request log.
+// fn is called once per request and suits cheap host-level metadata such as
+// the hostname or pod name; use WithContextLogFn for request-scoped values.
+func WithAttrsFunc(fn func() []slog.Attr) Option {
+	return func(o *Options) {
+		o.AttrsFn = fn
+	}
+}
+
+// WithLogErrorMeta adds the metadata attached to a connect.Error, which is
+// sent to the client as headers or trailers, as a redacted error_meta map
+// to failure logs.
+func WithLogErrorMeta(enabled bool) Option {
+	return func(o *Options) {
+		o.LogErrorMeta = enabled
+	}
+}
+
+// WithQuietAuthErrors logs Unauthenticated and PermissionDenied failures at
+// Info instead of Warn/Error, since they are common and rarely actionable.
+func WithQuietAuthErrors(enabled bool) Option {
+	return func(o *Options) {
+		o.QuietAuthErrors = enabled
+	}
+}
+
+// WithGenerateRequestID logs the request id from the given header as
+// request_id, generating a random one when the header is absent. Handlers
+// echo the id back in the response header or, for unary calls that fail,
+// in the metadata of a copy of the returned error (plain errors are
+// converted as Connect would); clients propagate a generated id to the
+// server.
+func WithGenerateRequestID(headerName string) Option {
+	return func(o *Options) {
+		o.RequestIDHeader = headerName
+	}
+}
+
+// WithSlowMessageThreshold logs a "slow stream message" warning whenever a
+// single stream Send or Receive takes at least d. Zero disables the check.
+func WithSlowMessageThreshold(d time.Duration) Option {
+	return func(o *Options) {
+		o.SlowMessageThreshold = d
+	}
+}
+
+// WithSlowMessageSample includes a truncated sample of the message in slow
+// stream message warnings even when debug logging is disabled.
+func WithSlowMessageSample(enabled bool) Option {
+	return func(o *Options) {
+		o.SlowMessageSample = enabled
+	}
+}
+
+// WithSkipEmptyStreams suppresses the completion log of streams that ended
+// without error and without sending or receiving any message.
+func WithSkipEmptyStreams(enabled bool) Option {
+	return func(o *Options) {
+		o.SkipEmptyStreams = enabled
+	}
+}
+
+// WithRedactAttrs masks the values of the named top-level log attributes,
+// such as values promoted from headers or context, before they are written.
+func WithRedactAttrs(keys ...string) Option {
+	return func(o *Options) {
+		o.RedactAttrs = append(o.RedactAttrs, keys...)
+	}
+}
+
+// WithLogMessageTypes adds the request and response message type names as
+// request_type and response_type to completion logs: the fully-qualified
+// proto name (e.g. acme.v1.CreateUserRequest) for proto messages and the Go
+// type name otherwise.
+func WithLogMessageTypes(enabled bool) Option {
+	return func(o *Options) {
+		o.LogMessageTypes = enabled
+	}
+}
+
+// WithSemanticConventions emits attributes following the OpenTelemetry RPC
+// semantic conventions: rpc.system, rpc.service and rpc.method replace
+// service and method, and completion logs carry rpc.grpc.status_code.
+func WithSemanticConventions(enabled bool) Option {
+	return func(o *Options) {
+		o.SemanticConventions = enabled
+	}
+}
+
+// WithRecoverToError recovers panics raised by handlers: the panic is logged
+// with its stack and the client receives an error with the given code
+// instead of the server goroutine crashing. Without this option panics are
+// logged and re-raised.
+func WithRecoverToError(code connect.Code) Option {
+	return func(o *Options) {
+		o.RecoverCode = code
+	}
+}
+
+// WithLogAcceptEncoding adds the compressions advertised by the client
+// (Accept-Encoding or its Connect/gRPC equivalents) as accept_encoding to
+// request logs, to debug compression negotiation.
+func WithLogAcceptEncoding(enabled bool) Option {
+	return func(o *Options) {
+		o.LogAcceptEncoding = enabled
+	}
+}
+
+// WithSamplingKey derives sampling decisions from the key returned by fn
+// (e.g. a trace or request id) instead of at random, so all logs of one
+// logical request are consistently sampled in or out. Calls with an empty
+// key fall back to random sampling.
+func WithSamplingKey(fn func(context.Context) string) Option {
+	return func(o *Options) {
+		o.SamplingKey = fn
+	}
+}
+
+// WithByteCounter adds the transport byte counts reported by fn as
+// wire_bytes_in and wire_bytes_out to stream completion logs.
+func WithByteCounter(fn ByteCounterFunc) Option {
+	return func(o *Options) {
+		o.ByteCounter = fn
+	}
+}
+
+// WithLogAuthScheme adds the scheme of the Authorization header (Bearer,
+// Basic, Negotiate) as auth_scheme to request logs. The credentials stay
+// redacted.
+func WithLogAuthScheme(enabled bool) Option {
+	return func(o *Options) {
+		o.LogAuthScheme = enabled
+	}
+}
+
+// WithOnError calls fn for every failed call after it has been logged, e.g.
+// to feed a circuit breaker or an error tracker. fn is called even when the
+// log record itself is sampled out.
+func WithOnError(fn func(ctx context.Context, info ErrorInfo)) Option {
+	return func(o *Options) {
+		o.OnError = fn
+	}
+}
+
+// WithRouteFromContext adds the route name returned by fn as route to
+// request logs, e.g. when the same handler is mounted under several routes.
+// Empty names are omitted.
+func WithRouteFromContext(fn func(context.Context) string) Option {
+	return func(o *Options) {
+		o.RouteFn = fn
+	}
+}
+
+// WithDurationRounding rounds logged durations to the nearest multiple of
+// d (e.g. time.Millisecond). Zero or negative values disable rounding.
+func WithDurationRounding(d time.Duration) Option {
+	return func(o *Options) {
+		o.DurationRounding = d
+	}
+}
+
+// WithEnvironment adds the deployment environment name (e.g. "production")
+// as env to all request logs.
+func WithEnvironment(name string) Option {
+	return func(o *Options) {
+		o.Environment = name
+	}
+}
+
+// WithStreamMessageBatch replaces the per-message stream debug logs with a
+// single "stream messages" record for every n messages, listing their
+// direction, number and size. The remainder is flushed when the stream
+// ends. Values below 2 keep one record per message.
+func WithStreamMessageBatch(n int) Option {
+	return func(o *Options) {
+		o.StreamMessageBatch = n
+	}
+}
+
+// WithResponseTransformer logs the value returned by fn instead of the
+// response body, e.g. to strip large blobs. It applies to unary responses
+// and messages sent on streams. fn must not modify its argument, which is
+// the real response: return a trimmed copy instead.
+func WithResponseTransformer(fn func(any) any) Option {
+	return func(o *Options) {
+		o.ResponseTransformer = fn
+	}
+}
+
+// WithPreHook calls fn before every call is passed to the next handler, as
+// an escape hatch for instrumentation that does not fit the logging model.
+// It runs regardless of the log level and sampling.
+func WithPreHook(fn PreHookFunc) Option {
+	return func(o *Options) {
+		o.PreHook = fn
+	}
+}
+
+// WithPostHook calls fn after every call with its error and duration. Like
+// WithPreHook it runs regardless of the log level and sampling.
+func WithPostHook(fn PostHookFunc) Option {
+	return func(o *Options) {
+		o.PostHook = fn
+	}
+}
+
+// WithTenantFromContext adds the tenant id returned by fn as tenant to
+// request logs of multi-tenant services. Empty ids are omitted.
+func WithTenantFromContext(fn func(context.Context) string) Option {
+	return func(o *Options) {
+		o.TenantFn = fn
+	}
+}
+
+// WithDebugTrigger logs a request at Debug level, with bodies and headers,
+// regardless of the logger level when fn returns true for its context, e.g.
+// for a flag set by an HTTP middleware from a troubleshooting header. The
+// wrapped handler must not filter levels in Handle.
+func WithDebugTrigger(fn func(context.Context) bool) Option {
+	return func(o *Options) {
+		o.DebugTrigger = fn
+	}
+}
+
+// WithNeverRedact logs the named headers in full, overriding both the
+// redact list and the built-in name heuristics (e.g. for
+// x-csrf-token-present). Matching is case-insensitive.
+func WithNeverRedact(headers ...string) Option {
+	return func(o *Options) {
+		o.NeverRedact = append(o.NeverRedact, headers...)
+	}
+}
+
+// WithLogTransportDetails adds a transport group with the HTTP method (GET
+// for Connect GET requests, POST otherwise) and the stream type to
+// completion logs.
+func WithLogTransportDetails(enabled bool) Option {
+	return func(o *Options) {
+		o.LogTransportDetails = enabled
+	}
+}
+
+// WithMaxBodyFields renders at most n populated fields of proto bodies and
+// adds a _more attribute with the number of omitted fields. In shape mode
+// the name list is capped the same way and ends with a "_more: K" entry.
+// Zero or negative values disable the limit.
+func WithMaxBodyFields(n int) Option {
+	return func(o *Options) {
+		o.MaxBodyFields = n
+	}
+}
+
+// WithSampleRates logs completed calls with a probability depending on
+// their resolved code, using defaultRate for codes missing from rates. Use
+// code 0 for successful calls, e.g. {0: 0.1, connect.CodeNotFound: 0.5}
+// with a default of 1 keeps all other errors. The rates apply in addition
+// to WithSmartSampling.
+func WithSampleRates(rates map[connect.Code]float64, defaultRate float64) Option {
+	return func(o *Options) {
+		o.SampleRates = make(map[connect.Code]float64, len(rates))
+		maps.Copy(o.SampleRates, rates)
+		o.DefaultSampleRate = defaultRate
+	}
+}
+
+// WithLogDeadline adds the time remaining until the context deadline as
+// deadline_in to the request and stream start logs.
+func WithLogDeadline(enabled bool) Option {
+	return func(o *Options) {
+		o.LogDeadline = enabled
+	}
+}
+
+// WithErrorLogger sends the logs of failed calls and panics to a dedicated
+// logger, e.g. an alerting pipeline, while other logs use the main logger.
+// Both carry the same request attributes.
+func WithErrorLogger(logger *slog.Logger) Option {
+	return func(o *Options) {
+		o.ErrorLogger = logger
+	}
+}
+
+// WithSequenceNumbers adds a per-interceptor, monotonically increasing seq
+// to completion and failure logs to order records sharing a timestamp.
+func WithSequenceNumbers(enabled bool) Option {
+	return func(o *Options) {
+		o.SequenceNumbers = enabled
+	}
+}
+
+// WithBodyAsJSON logs every body as JSON: proto messages via protojson,
+// other values via encoding/json, and []byte or string payloads as is when
+// they hold valid JSON or as {"raw": "..."} otherwise.
+func WithBodyAsJSON(enabled bool) Option {
+	return func(o *Options) {
+		o.BodyAsJSON = enabled
+	}
+}
+
+// WithCallerInfo adds the file:line of the user code driving a call as
+// caller to completion logs: the code calling a client (or its generated
+// stub) for client-side unary calls, the first sending or receiving handler
+// code for streams, and the first handler code calling RecordCaller for
+// server-side unary calls, whose handler is otherwise not on the stack.
+// Server-side unary handlers not calling it omit it.
+func WithCallerInfo(enabled bool) Option {
+	return func(o *Options) {
+		o.CallerInfo = enabled
+	}
+}
+
+// WithSummaryMessage replaces the completion log message with a compact
+// human-readable summary such as "POST FooService/Bar ok 12ms", keeping all
+// structured attributes. Useful for console output during development.
+func WithSummaryMessage(enabled bool) Option {
+	return func(o *Options) {
+		o.SummaryMessage = enabled
+	}
+}
+
+// WithBodyLoggingCodes limits debug body logging to calls resolving to one
+// of codes (use 0 for successful calls). The request body then moves from
+// the start log to the "response completed" or "response failed" debug log
+// written once the code is known.
+func WithBodyLoggingCodes(codes ...connect.Code) Option {
+	return func(o *Options) {
+		o.BodyLoggingCodes = append(o.BodyLoggingCodes, codes...)
+	}
+}
+
+// WithCombinedRPCAttr adds the short service name and method as a single
+// rpc attribute (e.g. "FooService/Bar") next to service and method.
+func WithCombinedRPCAttr(enabled bool) Option {
+	return func(o *Options) {
+		o.CombinedRPCAttr = enabled
+	}
+}
+
+// WithRedactCookies redacts only the values of the named cookies in Cookie
+// and Set-Cookie headers (e.g. "session=[REDACTED]; theme=dark"), keeping
+// other cookies and all cookie names visible. It takes precedence over the
+// redaction of the whole header.
+func WithRedactCookies(names []string) Option {
+	return func(o *Options) {
+		o.RedactCookies = slices.Clone(names)
+	}
+}
+
+// WithLargeRequestThreshold marks unary requests larger than n bytes with
+// large_request and logs their completion at least at Warn, even on
+// success, to surface potential abuse. Zero or negative values disable it.
+func WithLargeRequestThreshold(n int) Option {
+	return func(o *Options) {
+		o.LargeRequestThreshold = n
+	}
+}
+
+// WithGenericErrorMessages logs the canonical message of the error code
+// (e.g. "not found") as the error message for consistent grouping, keeping
+// the real message as detail_message.
+func WithGenericErrorMessages(enabled bool) Option {
+	return func(o *Options) {
+		o.GenericErrorMessages = enabled
+	}
+}
+
+// WithContext ties the background goroutines of the interceptor (such as
+// periodic summaries) to ctx: they stop when ctx is canceled or Close is
+// called. Defaults to context.Background().
+func WithContext(ctx context.Context) Option {
+	return func(o *Options) {
+		o.Context = ctx
+	}
+}
+
+// WithHashLongHeaders replaces header values longer than the
+// WithMaxHeaderValueLength limit with a short hash prefix such as
+// "sha256:ab12cd34ef56…" instead of truncated plaintext, so identical values
+// can be matched across logs without storing them.
+func WithHashLongHeaders(enabled bool) Option {
+	return func(o *Options) {
+		o.HashLongHeaders = enabled
+	}
+}
+
+// WithCacheHitFromContext adds cache_hit to completion logs when fn,
+// evaluated once the call completed, reports ok. Handlers typically record
+// the result in a mutable value placed in the context by a middleware.
+func WithCacheHitFromContext(fn func(ctx context.Context) (hit, ok bool)) Option {
+	return func(o *Options) {
+		o.CacheHitFn = fn
+	}
+}
+
+// WithLogTLSPeer adds the subject common name and serial number of the
+// verified client certificate as peer_cn and peer_serial to request logs.
+// The TLS state must be put in the context with ContextWithTLSState.
+func WithLogTLSPeer(enabled bool) Option {
+	return func(o *Options) {
+		o.LogTLSPeer = enabled
+	}
+}
+
+// WithLogIdempotency adds the idempotency level declared for the method
+// (no_side_effects or idempotent) as idempotency_level to request logs.
+// Methods without a declared level omit it.
+func WithLogIdempotency(enabled bool) Option {
+	return func(o *Options) {
+		o.LogIdempotency = enabled
+	}
+}
+
+// WithStreamMessageSummaryInterval replaces the per-message stream debug
+// logs with a "stream messages summary" record every d, holding the number
+// and total size of the messages exchanged since the previous summary.
+// Idle intervals are not logged and the remainder is logged when the stream
+// ends. It takes precedence over WithStreamMessageBatch.
+func WithStreamMessageSummaryInterval(d time.Duration) Option {
+	return func(o *Options) {
+		o.StreamSummaryInterval = d
+	}
+}
+
+// WithBodyAsYAML renders all logged bodies as YAML strings, which can be
+// easier to read than JSON for deeply nested messages. Proto messages are
+// converted through their protojson form. It takes precedence over
+// WithBodyAsJSON.
+func WithBodyAsYAML(enabled bool) Option {
+	return func(o *Options) {
+		o.BodyAsYAML = enabled
+	}
+}
+
+// WithStreamMessageSizes controls whether the size of every stream message
+// is computed for the per-message debug logs, batches and summaries. For
+// proto messages this costs a proto.Size call per message, which can be
+// disabled for high-rate streams. Enabled by default.
+func WithStreamMessageSizes(enabled bool) Option {
+	return func(o *Options) {
+		o.StreamMessageSizes = enabled
+	}
+}
+
+// WithErrorSampling logs failed calls with the listed codes with the given
+// probability (0 drops them, 1 logs them all), e.g. to thin out a storm of
+// invalid_argument errors. Codes not listed, including all server errors
+// unless explicitly added, are always logged. Error side effects such as
+// WithOnError are not sampled.
+func WithErrorSampling(rates map[connect.Code]float64) Option {
+	return func(o *Options) {
+		o.ErrorSampling = maps.Clone(rates)
+	}
+}
+
+// WithLogCodecSizes adds the binary and protojson encoded sizes of proto
+// bodies to the debug body logs as a request_sizes or response_sizes group
+// with proto_size and json_size, to help choosing between codecs. Only
+// debug records are affected, as each body is marshaled to JSON once more.
+func WithLogCodecSizes(enabled bool) Option {
+	return func(o *Options) {
+		o.LogCodecSizes = enabled
+	}
+}
+
+// WithPhaseTiming adds handler_duration, the time spent in the next handler
+// (or, for clients, the transport), to unary completion logs. The
+// difference to duration is the overhead of the interceptors up to and
+// including this one.
+func WithPhaseTiming(enabled bool) Option {
+	return func(o *Options) {
+		o.PhaseTiming = enabled
+	}
+}
+
+// WithRedactTokenLikeValues redacts headers whose value looks like a secret,
+// whatever the header name: JWTs and long base64 strings mixing letter
+// cases and digits. Headers listed in WithNeverRedact are kept.
+func WithRedactTokenLikeValues(enabled bool) Option {
+	return func(o *Options) {
+		o.RedactTokenLikeValues = enabled
+	}
+}
+
+// WithConcurrencyLogging adds in_flight, the number of calls (unary and
+// streaming) in progress on the interceptor, to completion logs.
+func WithConcurrencyLogging(enabled bool) Option {
+	return func(o *Options) {
+		o.ConcurrencyLogging = enabled
+	}
+}
+
+// WithLazyBodies defers the redaction and rendering of logged bodies until
+// the handler resolves the attribute, saving the work for records that a
+// handler drops. Handlers must resolve values before the call completes
+// (as the slog built-in handlers do), since the body may be reused later.
+// It has no effect with WithLogBodyShape, which changes the attribute key.
+func WithLazyBodies(enabled bool) Option {
+	return func(o *Options) {
+		o.LazyBodies = enabled
+	}
+}
+
+// WithProcedureAliases logs the procedures used as keys (e.g.
+// "/acme.foo.v1.FooService/Bar") under the name given as value (e.g.
+// "acme.Foo/Bar") in the service, method and rpc attributes. Options
+// matching procedures by service, such as WithServiceLoggers, still use the
+// real name.
+func WithProcedureAliases(aliases map[string]string) Option {
+	return func(o *Options) {
+		o.ProcedureAliases = maps.Clone(aliases)
+	}
+}
+
+// WithRedactor replaces the built-in header and body redaction with r. The
+// redaction options are then ignored, unless r delegates to a
+// DefaultRedactor built with them.
+func WithRedactor(r Redactor) Option {
+	return func(o *Options) {
+		o.Redactor = r
+	}
+}
+
+// WithAggregationWindow logs an "rpc summary" record per procedure every
+// window with the number of calls, the number of failed calls and the p50,
+// p95 and p99 durations. Percentiles are estimated with a fixed-size
+// histogram (about 5% relative error), so memory does not grow with the
+// number of calls. Per-call logs are not affected; the last window is
+// logged by Close.
+func WithAggregationWindow(window time.Duration) Option {
+	return func(o *Options) {
+		o.AggregationWindow = window
+	}
+}
+
+// WithLogCodec adds the codec selected by the client, parsed from the
+// request Content-Type (e.g. "proto" or "json"), as codec to request logs.
+func WithLogCodec(enabled bool) Option {
+	return func(o *Options) {
+		o.LogCodec = enabled
+	}
+}
+
+// WithContextValues logs the values stored in the request context under the
+// given keys, formatted with fmt, as attributes with the mapped names, e.g.
+// {userIDKey{}: "user_id"}. Missing keys are skipped. It covers the common
+// case of WithContextLogFn without a custom function.
+func WithContextValues(keys map[any]string) Option {
+	return func(o *Options) {
+		o.ContextValues = keys
+	}
+}
+
+// WithSlowThreshold flags unary calls lasting at least d with slow: true in
+// their completion log. Streams use WithSlowStreamThreshold instead. A value
+// of 0 disables the flag.
+func WithSlowThreshold(d time.Duration) Option {
+	return func(o *Options) {
+		o.SlowThreshold = d
+	}
+}
+
+// WithSlowStreamThreshold flags streams lasting at least d with slow: true
+// in their completion log. Streams are usually long-lived, so the threshold
+// is separate from (and typically much higher than) WithSlowThreshold. A
+// value of 0 disables the flag.
+func WithSlowStreamThreshold(d time.Duration) Option {
+	return func(o *Options) {
+		o.SlowStreamThreshold = d
+	}
+}
+
+// WithDetectSizeMismatch flags unary requests whose Content-Length differs
+// from the decoded request size with size_mismatch: true, to catch
+// truncated requests or proxy and client bugs. Only uncompressed binary
+// proto requests are checked, as the size is measured in the proto encoding.
+func WithDetectSizeMismatch(enabled bool) Option {
+	return func(o *Options) {
+		o.DetectSizeMismatch = enabled
+	}
+}
+
+// WithRedactBodyValuePatterns redacts string fields of proto bodies and
+// error details whose value matches any of patterns, whatever the field
+// name, e.g. to catch e-mail addresses or card numbers in unexpected
+// fields. It applies to any body logging mode.
+func WithRedactBodyValuePatterns(patterns []*regexp.Regexp) Option {
+	return func(o *Options) {
+		o.RedactBodyValuePatterns = slices.Clone(patterns)
+	}
+}
+
+// SchemaVersion is the version of the record format produced by this
+// package, logged as log_schema by WithSchemaVersion. It changes when
+// attributes are renamed or removed.
+const SchemaVersion = "1"
+
+// WithSchemaVersion adds log_schema with version v to all records, so that
+// log pipelines can handle format changes. An empty v uses SchemaVersion.
+func WithSchemaVersion(v string) Option {
+	return func(o *Options) {
+		if v == "" {
+			v = SchemaVersion
+		}
+		o.SchemaVersion = v
+	}
+}
+
+// WithStreamIdleWarning logs a "stream idle" warning with the time since
+// the last message when a stream sends and receives nothing for d. It is
+// logged once per idle period and rearmed by the next message. A value of 0
+// disables the warning.
+func WithStreamIdleWarning(d time.Duration) Option {
+	return func(o *Options) {
+		o.StreamIdleWarning = d
+	}
+}
+
+// WithSpanStyleLogging logs every call as a pair of "rpc.start" and
+// "rpc.end" records sharing a random span_id: the start is logged when the
+// call begins, the end carries the error and the duration. The end record
+// replaces the completion message and WithSummaryMessage.
+//
+// As the start is logged before the outcome is known, every started call
+// is ended: sampling (WithSmartSampling, WithSampleRates, WithErrorSampling) and
+// WithSkipEmptyStreams don't apply. Bodies follow the debug body logging
+// rules: the request is added to the start and the response to the end only
+// when debug logging is enabled for the call and WithBodyLoggingCodes allows
+// it.
+func WithSpanStyleLogging(enabled bool) Option {
+	return func(o *Options) {
+		o.SpanStyleLogging = enabled
+	}
+}
@@ -0,0 +1,111 @@
+package connectlog
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"runtime"
+	"strings"
+
+	"connectrpc.com/connect"
+)
+
+// errPanicRecovered is the cause of errors returned for recovered panics;
+// the panic value itself is only logged, never sent to the client.
+var errPanicRecovered = errors.New("panic recovered")
+
+// callUnary calls next, logging and recovering its panics when configured.
+func (i *LoggingInterceptor) callUnary(ctx context.Context, logger *slog.Logger, next connect.UnaryFunc, req connect.AnyRequest) (res connect.AnyResponse, err error) {
+	if i.handlesPanics() {
+		defer i.recoverPanic(ctx, logger, &err)
+	}
+	return next(ctx, req)
+}
+
+// callStream calls next, logging and recovering its panics when configured.
+func (i *LoggingInterceptor) callStream(ctx context.Context, logger *slog.Logger, next connect.StreamingHandlerFunc, conn connect.StreamingHandlerConn) (err error) {
+	if i.handlesPanics() {
+		defer i.recoverPanic(ctx, logger, &err)
+	}
+	return next(ctx, conn)
+}
+
+// handlesPanics reports whether handler panics are logged or recovered;
+// otherwise they propagate untouched.
+func (i *LoggingInterceptor) handlesPanics() bool {
+	return i.options.LogPanics || i.options.RecoverCode != 0
+}
+
+// recoverPanic logs a panic raised by the next handler together with its
+// stack trace. With WithRecoverToError the panic is converted into an error
+// with the configured code stored in errp; otherwise it re-panics with the
+// same value. It must be deferred directly.
+func (i *LoggingInterceptor) recoverPanic(ctx context.Context, logger *slog.Logger, errp *error) {
+	r := recover()
+	if r == nil {
+		return
+	}
+
+	logger.ErrorContext(ctx, "request panicked",
+		slog.Any("panic", r),
+		slog.String("stack", captureStack(i.options.PanicStackDepth)),
+	)
+
+	if i.options.RecoverCode == 0 {
+		panic(r)
+	}
+	*errp = connect.NewError(i.options.RecoverCode, errPanicRecovered)
+}
+
+// captureStack formats the stack of the panicking goroutine, one frame per
+// line, starting at the frame that raised the panic. At most depth frames
+// are included; depth <= 0 captures the full stack.
+func captureStack(depth int) string {
+	size := depth
+	if size <= 0 {
+		size = 64
+	}
+
+	var pcs []uintptr
+	for {
+		// skip runtime.Callers, captureStack and recoverPanic
+		pcs = make([]uintptr, size+8)
+		n := runtime.Callers(3, pcs)
+		if n < len(pcs) || depth > 0 {
+			pcs = pcs[:n]
+			break
+		}
+		size *= 2
+	}
+
+	var sb strings.Builder
+	frames := runtime.CallersFrames(pcs)
+	written, inPanic := 0, true
+	for {
+		frame, more := frames.Next()
+		// skip the runtime panic machinery above the panicking frame
+		if inPanic && strings.HasPrefix(frame.Function, "runtime.") {
+			if !more {
+				break
+			}
+			continue
+		}
+		inPanic = false
+
+		if depth > 0 && written == depth {
+			break
+		}
+		if written > 0 {
+			sb.WriteByte('\n')
+		}
+		fmt.Fprintf(&sb, "%s (%s:%d)", frame.Function, frame.File, frame.Line)
+		written++
+
+		if !more {
+			break
+		}
+	}
+
+	return sb.String()
+}
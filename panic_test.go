@@ -0,0 +1,121 @@
+package connectlog
+
+import (
+	"context"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"connectrpc.com/connect"
+)
+
+func deepPanic(depth int) {
+	if depth == 0 {
+		panic("boom")
+	}
+	deepPanic(depth - 1)
+}
+
+func TestLogPanic(t *testing.T) {
+	tests := []struct {
+		name      string
+		depth     int
+		maxFrames int
+	}{
+		{name: "limited stack", depth: 3, maxFrames: 3},
+		{name: "full stack", depth: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logger, buf := newTestLogger(slog.LevelInfo)
+			interceptor := NewLoggingInterceptor(WithLogger(logger), WithLogPanics(true), WithPanicStackDepth(tt.depth))
+
+			func() {
+				defer func() {
+					if r := recover(); r != "boom" {
+						t.Errorf("expected panic to be re-raised, got %v", r)
+					}
+				}()
+				req := newTestRequest(testProcedure, &struct{}{})
+				_, _ = callUnary(t, interceptor, req, func(context.Context, connect.AnyRequest) (connect.AnyResponse, error) {
+					deepPanic(10)
+					return nil, nil
+				})
+			}()
+
+			record := findRecord(t, logRecords(t, buf), "request panicked")
+			if got := record["panic"]; got != "boom" {
+				t.Errorf("expected panic value boom, got %v", got)
+			}
+
+			stack, _ := record["stack"].(string)
+			frames := strings.Split(stack, "\n")
+			if !strings.Contains(frames[0], "deepPanic") {
+				t.Errorf("expected stack to start at the panicking frame, got %q", frames[0])
+			}
+			if tt.maxFrames > 0 && len(frames) > tt.maxFrames {
+				t.Errorf("expected at most %d frames, got %d", tt.maxFrames, len(frames))
+			}
+			if tt.maxFrames == 0 && len(frames) <= 11 {
+				t.Errorf("expected full stack, got %d frames", len(frames))
+			}
+		})
+	}
+}
+
+func TestWithRecoverToError(t *testing.T) {
+	logger, buf := newTestLogger(slog.LevelInfo)
+	interceptor := NewLoggingInterceptor(WithLogger(logger), WithRecoverToError(connect.CodeInternal))
+
+	req := newTestRequest(testProcedure, &struct{}{})
+	_, err := callUnary(t, interceptor, req, func(context.Context, connect.AnyRequest) (connect.AnyResponse, error) {
+		panic("boom")
+	})
+	if got := connect.CodeOf(err); got != connect.CodeInternal {
+		t.Fatalf("expected client to receive %v, got %v", connect.CodeInternal, err)
+	}
+
+	stream := interceptor.WrapStreamingHandler(func(context.Context, connect.StreamingHandlerConn) error {
+		panic("boom")
+	})
+	if err := stream(context.Background(), newTestStreamConn(connect.StreamTypeBidi, 0)); connect.CodeOf(err) != connect.CodeInternal {
+		t.Fatalf("expected stream to return %v, got %v", connect.CodeInternal, err)
+	}
+
+	records := logRecords(t, buf)
+	panics := 0
+	for _, record := range records {
+		if record[slog.MessageKey] == "request panicked" {
+			panics++
+		}
+	}
+	if panics != 2 {
+		t.Errorf("expected 2 panic logs, got %d", panics)
+	}
+	findRecord(t, records, "request failed")
+	findRecord(t, records, "stream failed")
+}
+
+func TestPanicNotLoggedByDefault(t *testing.T) {
+	logger, buf := newTestLogger(slog.LevelInfo)
+	interceptor := NewLoggingInterceptor(WithLogger(logger))
+
+	func() {
+		defer func() {
+			if r := recover(); r != "boom" {
+				t.Errorf("expected panic to propagate, got %v", r)
+			}
+		}()
+		req := newTestRequest(testProcedure, &struct{}{})
+		_, _ = callUnary(t, interceptor, req, func(context.Context, connect.AnyRequest) (connect.AnyResponse, error) {
+			panic("boom")
+		})
+	}()
+
+	for _, record := range logRecords(t, buf) {
+		if record[slog.MessageKey] == "request panicked" {
+			t.Errorf("expected no panic log without WithLogPanics, got %v", record)
+		}
+	}
+}
@@ -0,0 +1,63 @@
+package connectlog
+
+import (
+	"log/slog"
+	"strings"
+)
+
+// procedureInfo holds the parsed parts of a procedure name and the
+// pre-built log attributes identifying it.
+type procedureInfo struct {
+	service string // real service name, even when the procedure is aliased
+	method  string
+	rpc     string // short service name and method, e.g. "FooService/Bar"
+	attrs   []slog.Attr
+}
+
+// procedureInfo returns the parsed procedure, caching it so repeated calls
+// to the same procedure skip parsing and attribute allocation.
+func (i *LoggingInterceptor) procedureInfo(procedure string) *procedureInfo {
+	if info, ok := i.procedures.Load(procedure); ok {
+		return info.(*procedureInfo)
+	}
+
+	info := parseProcedure(procedure, i.options.SemanticConventions)
+	if alias, ok := i.options.ProcedureAliases[procedure]; ok {
+		// Log the alias, but keep selecting service loggers by the real name
+		service := info.service
+		info = parseProcedure(alias, i.options.SemanticConventions)
+		info.service = service
+	}
+
+	cached, _ := i.procedures.LoadOrStore(procedure, info)
+	return cached.(*procedureInfo)
+}
+
+// parseProcedure splits a procedure like "/acme.foo.v1.FooService/Bar" into
+// its service and method and builds the corresponding attributes.
+func parseProcedure(procedure string, semconv bool) *procedureInfo {
+	procedure = strings.TrimPrefix(procedure, "/")
+	service, method, ok := strings.Cut(procedure, "/")
+	if !ok {
+		service, method = "", procedure
+	}
+
+	info := &procedureInfo{service: service, method: method, rpc: method}
+	if service != "" {
+		info.rpc = service[strings.LastIndexByte(service, '.')+1:] + "/" + method
+	}
+	if semconv {
+		// OpenTelemetry RPC semantic conventions
+		info.attrs = []slog.Attr{
+			slog.String("rpc.service", service),
+			slog.String("rpc.method", method),
+		}
+	} else {
+		info.attrs = []slog.Attr{
+			slog.String("service", service),
+			slog.String("method", method),
+		}
+	}
+
+	return info
+}
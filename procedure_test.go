@@ -0,0 +1,109 @@
+package connectlog
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"testing"
+
+	"connectrpc.com/connect"
+)
+
+func TestParseProcedure(t *testing.T) {
+	tests := []struct {
+		procedure string
+		service   string
+		method    string
+		rpc       string
+	}{
+		{procedure: "/acme.foo.v1.FooService/Bar", service: "acme.foo.v1.FooService", method: "Bar", rpc: "FooService/Bar"},
+		{procedure: "acme.foo.v1.FooService/Bar", service: "acme.foo.v1.FooService", method: "Bar", rpc: "FooService/Bar"},
+		{procedure: "/FooService/Bar", service: "FooService", method: "Bar", rpc: "FooService/Bar"},
+		{procedure: "/Bar", service: "", method: "Bar", rpc: "Bar"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.procedure, func(t *testing.T) {
+			info := parseProcedure(tt.procedure, false)
+			if info.service != tt.service || info.method != tt.method {
+				t.Errorf("expected %q/%q, got %q/%q", tt.service, tt.method, info.service, info.method)
+			}
+			if info.rpc != tt.rpc {
+				t.Errorf("expected rpc %q, got %q", tt.rpc, info.rpc)
+			}
+		})
+	}
+}
+
+func TestProcedureInfoCache(t *testing.T) {
+	interceptor := NewLoggingInterceptor()
+	first := interceptor.procedureInfo(testProcedure)
+	if second := interceptor.procedureInfo(testProcedure); first != second {
+		t.Error("expected cached procedure info to be reused")
+	}
+}
+
+func BenchmarkProcedureInfo(b *testing.B) {
+	b.Run("uncached", func(b *testing.B) {
+		b.ReportAllocs()
+		for b.Loop() {
+			parseProcedure(testProcedure, false)
+		}
+	})
+
+	b.Run("cached", func(b *testing.B) {
+		interceptor := NewLoggingInterceptor()
+		b.ReportAllocs()
+		for b.Loop() {
+			interceptor.procedureInfo(testProcedure)
+		}
+	})
+}
+
+func BenchmarkInitRequestLogger(b *testing.B) {
+	interceptor := NewLoggingInterceptor(WithLogger(nil))
+	spec := connect.Spec{Procedure: testProcedure}
+	peer := connect.Peer{Addr: "127.0.0.1:12345", Protocol: connect.ProtocolConnect}
+	header := make(http.Header)
+
+	b.ReportAllocs()
+	for b.Loop() {
+		interceptor.initRequestLogger(context.Background(), spec, peer, header)
+	}
+}
+
+func TestWithProcedureAliases(t *testing.T) {
+	defaultLogger, defaultBuf := newTestLogger(slog.LevelInfo)
+	serviceLogger, serviceBuf := newTestLogger(slog.LevelInfo)
+	interceptor := NewLoggingInterceptor(
+		WithLogger(defaultLogger),
+		WithCombinedRPCAttr(true),
+		WithServiceLoggers(map[string]*slog.Logger{"acme.test.v1.TestService": serviceLogger}),
+		WithProcedureAliases(map[string]string{testProcedure: "acme.Test/Ping"}),
+	)
+
+	_, _ = callUnary(t, interceptor, newTestRequest(testProcedure, &struct{}{}), func(context.Context, connect.AnyRequest) (connect.AnyResponse, error) {
+		return connect.NewResponse(&struct{}{}), nil
+	})
+
+	if got := len(logRecords(t, defaultBuf)); got != 0 {
+		t.Errorf("expected the service logger to be selected by the real name, got %d default records", got)
+	}
+	record := findRecord(t, logRecords(t, serviceBuf), "request completed")
+	for key, expected := range map[string]string{"service": "acme.Test", "method": "Ping", "rpc": "Test/Ping"} {
+		if got := record[key]; got != expected {
+			t.Errorf("expected %s %q, got %v", key, expected, got)
+		}
+	}
+}
+
+func TestWithProcedureAliasesCopiesAliases(t *testing.T) {
+	aliases := map[string]string{testProcedure: "acme.Test/Ping"}
+	var options Options
+	WithProcedureAliases(aliases)(&options)
+
+	aliases[testProcedure] = "acme.Test/Changed"
+	if got := options.ProcedureAliases[testProcedure]; got != "acme.Test/Ping" {
+		t.Errorf("expected the aliases to be copied, got %q", got)
+	}
+}
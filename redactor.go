@@ -0,0 +1,66 @@
+package connectlog
+
+import (
+	"regexp"
+
+	"google.golang.org/protobuf/proto"
+)
+
+// Redactor removes sensitive data from the headers (including error
+// metadata) and bodies before they are logged. Implementations must not
+// modify their arguments and must be safe for concurrent use.
+type Redactor interface {
+	RedactHeaders(headers map[string][]string) map[string][]string
+	RedactBody(body any) any
+}
+
+// DefaultRedactor is the Redactor used unless WithRedactor is given. It
+// applies the header and body redaction options: WithRedactHeaders,
+// WithNeverRedact, WithRedactCookies, WithRedactTokenLikeValues,
+// WithMaxHeaderValueLength, WithHashLongHeaders and
+// WithRedactBodyValuePatterns.
+//
+// Custom redactors can embed it to extend the default behavior.
+type DefaultRedactor struct {
+	headers    headerRedaction
+	bodyValues []*regexp.Regexp
+}
+
+var _ Redactor = (*DefaultRedactor)(nil)
+
+// NewDefaultRedactor creates the default redactor configured by the
+// redaction options among opts; other options are ignored.
+func NewDefaultRedactor(opts ...Option) *DefaultRedactor {
+	return newDefaultRedactor(newOptions(opts))
+}
+
+func newDefaultRedactor(options Options) *DefaultRedactor {
+	return &DefaultRedactor{
+		headers:    newHeaderRedaction(options),
+		bodyValues: options.RedactBodyValuePatterns,
+	}
+}
+
+// RedactHeaders redacts sensitive header values and truncates long ones.
+func (r *DefaultRedactor) RedactHeaders(headers map[string][]string) map[string][]string {
+	return redactHeadersMap(headers, &r.headers)
+}
+
+// RedactBody returns body with the string values matching
+// WithRedactBodyValuePatterns redacted. Only proto messages are supported;
+// the original message is never modified.
+func (r *DefaultRedactor) RedactBody(body any) any {
+	if len(r.bodyValues) == 0 {
+		return body
+	}
+	msg, ok := body.(proto.Message)
+	if !ok || !msg.ProtoReflect().IsValid() {
+		return body
+	}
+
+	clone := proto.Clone(msg)
+	if !redactProtoValues(clone.ProtoReflect(), r.bodyValues) {
+		return msg
+	}
+	return clone
+}
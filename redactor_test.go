@@ -0,0 +1,94 @@
+package connectlog
+
+import (
+	"context"
+	"log/slog"
+	"reflect"
+	"regexp"
+	"testing"
+
+	"connectrpc.com/connect"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/typepb"
+)
+
+// internalRedactor extends the default redaction by hiding internal headers
+// and all bodies.
+type internalRedactor struct {
+	*DefaultRedactor
+}
+
+func (r internalRedactor) RedactHeaders(headers map[string][]string) map[string][]string {
+	redacted := r.DefaultRedactor.RedactHeaders(headers)
+	if _, ok := redacted["X-Internal"]; ok {
+		redacted["X-Internal"] = []string{redactedValue}
+	}
+	return redacted
+}
+
+func (internalRedactor) RedactBody(any) any {
+	return "[BODY]"
+}
+
+func TestWithRedactor(t *testing.T) {
+	logger, buf := newTestLogger(slog.LevelDebug)
+	interceptor := NewLoggingInterceptor(WithLogger(logger), WithRedactor(internalRedactor{NewDefaultRedactor()}))
+
+	req := newTestRequest(testProcedure, &blobResponse{Name: "secret"})
+	req.Header().Set("Authorization", "Bearer secret")
+	req.Header().Set("X-Internal", "node-7")
+	req.Header().Set("X-Public", "visible")
+	_, _ = callUnary(t, interceptor, req, func(context.Context, connect.AnyRequest) (connect.AnyResponse, error) {
+		return connect.NewResponse(&struct{}{}), nil
+	})
+
+	record := findRecord(t, logRecords(t, buf), "request started")
+	if got := record["request"]; got != "[BODY]" {
+		t.Errorf("expected the custom body redaction, got %v", got)
+	}
+	headers, _ := record["headers"].(map[string]any)
+	expected := map[string]any{
+		"Authorization": []any{redactedValue},
+		"X-Internal":    []any{redactedValue},
+		"X-Public":      []any{"visible"},
+	}
+	if !reflect.DeepEqual(headers, expected) {
+		t.Errorf("expected headers %v, got %v", expected, headers)
+	}
+}
+
+func TestWithRedactBodyValuePatterns(t *testing.T) {
+	email := regexp.MustCompile(`[\w.+-]+@[\w-]+\.[\w.]+`)
+	interceptor := NewLoggingInterceptor(WithRedactBodyValuePatterns([]*regexp.Regexp{email}))
+
+	body := &typepb.Type{
+		Name:   "acme.Contact",
+		Oneofs: []string{"primary", "john.doe@example.com"},
+		Fields: []*typepb.Field{{Name: "note", DefaultValue: "write to jane@example.org"}},
+	}
+	redacted, _ := interceptor.redactor.RedactBody(body).(*typepb.Type)
+
+	expected := &typepb.Type{
+		Name:   "acme.Contact",
+		Oneofs: []string{"primary", redactedValue},
+		Fields: []*typepb.Field{{Name: "note", DefaultValue: redactedValue}},
+	}
+	if !proto.Equal(redacted, expected) {
+		t.Errorf("expected %v, got %v", expected, redacted)
+	}
+	if body.Oneofs[1] != "john.doe@example.com" {
+		t.Error("expected the original body to be left unchanged")
+	}
+}
+
+func TestWithRedactBodyValuePatternsCopiesPatterns(t *testing.T) {
+	email := regexp.MustCompile(`[\w.+-]+@[\w-]+\.[\w.]+`)
+	patterns := []*regexp.Regexp{email}
+	var options Options
+	WithRedactBodyValuePatterns(patterns)(&options)
+
+	patterns[0] = regexp.MustCompile(`.*`)
+	if options.RedactBodyValuePatterns[0] != email {
+		t.Error("expected the patterns to be copied")
+	}
+}
@@ -0,0 +1,90 @@
+package connectlog
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"os"
+	"slices"
+
+	"connectrpc.com/connect"
+)
+
+// requestID returns the request id from the configured header, generating
+// a new one when the header is absent. It returns an empty string when
+// request id generation is disabled.
+func (i *LoggingInterceptor) requestID(header http.Header) string {
+	if i.options.RequestIDHeader == "" {
+		return ""
+	}
+	if id := header.Get(i.options.RequestIDHeader); id != "" {
+		return id
+	}
+	return newRequestID()
+}
+
+// newRequestID generates a random 128-bit hex-encoded request id.
+func newRequestID() string {
+	var buf [16]byte
+	_, _ = rand.Read(buf[:])
+	return hex.EncodeToString(buf[:])
+}
+
+// newSpanID generates a random 64-bit hex-encoded span id.
+func newSpanID() string {
+	var buf [8]byte
+	_, _ = rand.Read(buf[:])
+	return hex.EncodeToString(buf[:])
+}
+
+// echoRequestID sets the request id on the unary response headers, or on
+// the error metadata when the call failed, so the client can correlate it.
+// It returns the error to send: on failure, a copy of the error Connect
+// would send with the id added, as the handler's error may be a shared
+// sentinel.
+func (i *LoggingInterceptor) echoRequestID(id string, res connect.AnyResponse, err error) error {
+	if id == "" {
+		return err
+	}
+	if err == nil {
+		if res != nil {
+			res.Header().Set(i.options.RequestIDHeader, id)
+		}
+		return nil
+	}
+
+	echoed := copyConnectError(err)
+	echoed.Meta().Set(i.options.RequestIDHeader, id)
+	return echoed
+}
+
+// copyConnectError returns a copy of the Connect error in err, or of the
+// error Connect sends for a plain error: Canceled or DeadlineExceeded for
+// context errors, Unknown otherwise. Metadata of wire errors, which Connect
+// doesn't send, is not copied.
+func copyConnectError(err error) *connect.Error {
+	var connectErr *connect.Error
+	if !errors.As(err, &connectErr) {
+		code := connect.CodeUnknown
+		switch {
+		case errors.Is(err, context.Canceled):
+			code = connect.CodeCanceled
+		case errors.Is(err, context.DeadlineExceeded) || errors.Is(err, os.ErrDeadlineExceeded):
+			code = connect.CodeDeadlineExceeded
+		}
+		return connect.NewError(code, err)
+	}
+
+	clone := connect.NewError(connectErr.Code(), connectErr.Unwrap())
+	for _, detail := range connectErr.Details() {
+		clone.AddDetail(detail)
+	}
+	if !connect.IsWireError(connectErr) {
+		for key, values := range connectErr.Meta() {
+			clone.Meta()[key] = slices.Clone(values)
+		}
+	}
+	return clone
+}
@@ -0,0 +1,112 @@
+package connectlog
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"testing"
+
+	"connectrpc.com/connect"
+)
+
+func TestWithGenerateRequestID(t *testing.T) {
+	const header = "X-Request-Id"
+
+	tests := []struct {
+		name     string
+		incoming string
+		err      error
+	}{
+		{name: "present", incoming: "req-123"},
+		{name: "absent"},
+		{name: "absent on error", err: connect.NewError(connect.CodeNotFound, errors.New("missing"))},
+		{name: "absent on plain error", err: errors.New("missing")},
+		{name: "absent on context error", err: context.Canceled},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logger, buf := newTestLogger(slog.LevelInfo)
+			interceptor := NewLoggingInterceptor(WithLogger(logger), WithGenerateRequestID(header))
+
+			req := newTestRequest(testProcedure, &struct{}{})
+			if tt.incoming != "" {
+				req.Header().Set(header, tt.incoming)
+			}
+
+			res, err := callUnary(t, interceptor, req, func(context.Context, connect.AnyRequest) (connect.AnyResponse, error) {
+				if tt.err != nil {
+					return nil, tt.err
+				}
+				return connect.NewResponse(&struct{}{}), nil
+			})
+
+			logged, _ := logRecords(t, buf)[0]["request_id"].(string)
+			if tt.incoming != "" && logged != tt.incoming {
+				t.Errorf("expected incoming request_id %q, got %q", tt.incoming, logged)
+			}
+			if tt.incoming == "" && len(logged) != 32 {
+				t.Errorf("expected generated request_id, got %q", logged)
+			}
+
+			var echoed string
+			if res != nil {
+				echoed = res.Header().Get(header)
+			} else {
+				var connectErr *connect.Error
+				if errors.As(err, &connectErr) {
+					echoed = connectErr.Meta().Get(header)
+				}
+			}
+			if echoed != logged {
+				t.Errorf("expected request id %q echoed to the client, got %q", logged, echoed)
+			}
+			if expected := resultCode(tt.err); connect.CodeOf(err) != expected && tt.err != nil {
+				t.Errorf("expected code %v, got %v", expected, connect.CodeOf(err))
+			}
+		})
+	}
+}
+
+func TestEchoRequestIDSharedError(t *testing.T) {
+	const header = "X-Request-Id"
+	sentinel := connect.NewError(connect.CodeNotFound, errors.New("missing"))
+	sentinel.Meta().Set("X-Reason", "gone")
+	interceptor := NewLoggingInterceptor(WithLogger(slog.New(discardHandler{})), WithGenerateRequestID(header))
+
+	_, err := callUnary(t, interceptor, newTestRequest(testProcedure, &struct{}{}), func(context.Context, connect.AnyRequest) (connect.AnyResponse, error) {
+		return nil, sentinel
+	})
+
+	if got := sentinel.Meta().Get(header); got != "" {
+		t.Errorf("expected the handler's error to be left unchanged, got request id %q", got)
+	}
+	var connectErr *connect.Error
+	if !errors.As(err, &connectErr) || connectErr.Meta().Get(header) == "" || connectErr.Meta().Get("X-Reason") != "gone" {
+		t.Errorf("expected a copy with the request id and the original metadata, got %v", err)
+	}
+	if connectErr.Message() != "missing" {
+		t.Errorf("expected the message to be kept, got %q", connectErr.Message())
+	}
+}
+
+func TestWithGenerateRequestID_Stream(t *testing.T) {
+	const header = "X-Request-Id"
+
+	logger, buf := newTestLogger(slog.LevelInfo)
+	interceptor := NewLoggingInterceptor(WithLogger(logger), WithGenerateRequestID(header))
+
+	conn := newTestStreamConn(connect.StreamTypeServer, 0)
+	handler := interceptor.WrapStreamingHandler(func(context.Context, connect.StreamingHandlerConn) error {
+		return nil
+	})
+	_ = handler(context.Background(), conn)
+
+	logged, _ := findRecord(t, logRecords(t, buf), "stream completed")["request_id"].(string)
+	if logged == "" {
+		t.Fatal("expected generated request_id")
+	}
+	if got := conn.ResponseHeader().Get(header); got != logged {
+		t.Errorf("expected request id %q in response header, got %q", logged, got)
+	}
+}
@@ -0,0 +1,95 @@
+package connectlog
+
+import (
+	"context"
+	"hash/fnv"
+	"math"
+	"math/rand/v2"
+	"time"
+
+	"connectrpc.com/connect"
+)
+
+// SamplingConfig combines error, latency and random sampling into a single
+// policy deciding which completed calls are logged.
+//
+// The rules are applied in order and the first matching one wins:
+//  1. failed calls are logged if AlwaysLogErrors is set;
+//  2. calls lasting at least SlowThreshold (when > 0) are logged;
+//  3. all remaining calls are logged with probability FastSampleRate
+//     (0 drops them, 1 logs them all). With WithSamplingKey the decision is
+//     derived from the key, so calls sharing a key are sampled together.
+type SamplingConfig struct {
+	AlwaysLogErrors bool
+	SlowThreshold   time.Duration
+	FastSampleRate  float64
+}
+
+// sampled reports whether a completed call with the given code (0 on
+// success) should be logged. The call must pass the SamplingConfig, the
+// error sampling rates and the per-code rates when they are configured.
+func (i *LoggingInterceptor) sampled(ctx context.Context, code connect.Code, duration time.Duration) bool {
+	if !i.sampledByConfig(ctx, code != 0, duration) {
+		return false
+	}
+	if rate, ok := i.options.ErrorSampling[code]; ok && code != 0 && !i.sampleRate(ctx, rate) {
+		return false
+	}
+	if i.options.SampleRates == nil {
+		return true
+	}
+
+	rate, ok := i.options.SampleRates[code]
+	if !ok {
+		rate = i.options.DefaultSampleRate
+	}
+	return i.sampleRate(ctx, rate)
+}
+
+// sampledByConfig applies the SamplingConfig policy.
+func (i *LoggingInterceptor) sampledByConfig(ctx context.Context, failed bool, duration time.Duration) bool {
+	cfg := i.options.Sampling
+	if cfg == nil {
+		return true
+	}
+
+	switch {
+	case failed && cfg.AlwaysLogErrors:
+		return true
+	case cfg.SlowThreshold > 0 && duration >= cfg.SlowThreshold:
+		return true
+	default:
+		return i.sampleRate(ctx, cfg.FastSampleRate)
+	}
+}
+
+// sampleRate makes a sampling decision that is true with probability rate,
+// either deterministically from the sampling key or at random.
+func (i *LoggingInterceptor) sampleRate(ctx context.Context, rate float64) bool {
+	if i.options.SamplingKey != nil {
+		if key := i.options.SamplingKey(ctx); key != "" {
+			return keyedSampleRate(key, rate)
+		}
+	}
+	return sampleRate(rate)
+}
+
+// keyedSampleRate makes a decision that is true with probability rate and
+// always the same for the same key.
+func keyedSampleRate(key string, rate float64) bool {
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(key))
+	return float64(h.Sum64())/math.MaxUint64 < rate
+}
+
+// sampleRate makes a random decision that is true with probability rate.
+func sampleRate(rate float64) bool {
+	switch {
+	case rate <= 0:
+		return false
+	case rate >= 1:
+		return true
+	default:
+		return rand.Float64() < rate
+	}
+}
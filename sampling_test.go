@@ -0,0 +1,234 @@
+package connectlog
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"testing"
+	"time"
+
+	"connectrpc.com/connect"
+)
+
+func TestWithSmartSampling(t *testing.T) {
+	tests := []struct {
+		name     string
+		config   SamplingConfig
+		failed   bool
+		duration time.Duration
+		logged   bool
+	}{
+		{
+			name:   "error always logged",
+			config: SamplingConfig{AlwaysLogErrors: true},
+			failed: true,
+			logged: true,
+		},
+		{
+			name:   "error sampled when not always logged",
+			config: SamplingConfig{},
+			failed: true,
+			logged: false,
+		},
+		{
+			name:     "slow call always logged",
+			config:   SamplingConfig{SlowThreshold: time.Millisecond},
+			duration: 5 * time.Millisecond,
+			logged:   true,
+		},
+		{
+			name:   "fast call dropped at zero rate",
+			config: SamplingConfig{AlwaysLogErrors: true, SlowThreshold: time.Hour},
+			logged: false,
+		},
+		{
+			name:   "fast call logged at full rate",
+			config: SamplingConfig{SlowThreshold: time.Hour, FastSampleRate: 1},
+			logged: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logger, buf := newTestLogger(slog.LevelInfo)
+			interceptor := NewLoggingInterceptor(WithLogger(logger), WithSmartSampling(tt.config))
+
+			req := newTestRequest(testProcedure, &struct{}{})
+			_, _ = callUnary(t, interceptor, req, func(context.Context, connect.AnyRequest) (connect.AnyResponse, error) {
+				time.Sleep(tt.duration)
+				if tt.failed {
+					return nil, connect.NewError(connect.CodeInternal, errors.New("failed"))
+				}
+				return connect.NewResponse(&struct{}{}), nil
+			})
+
+			if got := len(logRecords(t, buf)) > 0; got != tt.logged {
+				t.Errorf("expected logged %v, got %v", tt.logged, got)
+			}
+		})
+	}
+}
+
+func TestSampleRate(t *testing.T) {
+	const calls = 10000
+
+	logged := 0
+	for range calls {
+		if sampleRate(0.1) {
+			logged++
+		}
+	}
+	if logged < calls*5/100 || logged > calls*15/100 {
+		t.Errorf("expected about 10%% of calls sampled, got %d of %d", logged, calls)
+	}
+}
+
+type traceIDKey struct{}
+
+func TestWithSamplingKey(t *testing.T) {
+	logger, buf := newTestLogger(slog.LevelInfo)
+	interceptor := NewLoggingInterceptor(
+		WithLogger(logger),
+		WithSmartSampling(SamplingConfig{FastSampleRate: 0.5}),
+		WithSamplingKey(func(ctx context.Context) string {
+			id, _ := ctx.Value(traceIDKey{}).(string)
+			return id
+		}),
+	)
+
+	handler := interceptor.WrapUnary(func(context.Context, connect.AnyRequest) (connect.AnyResponse, error) {
+		return connect.NewResponse(&struct{}{}), nil
+	})
+	logged := func(traceID string) bool {
+		buf.Reset()
+		ctx := context.WithValue(context.Background(), traceIDKey{}, traceID)
+		_, _ = handler(ctx, newTestRequest(testProcedure, &struct{}{}))
+		return buf.Len() > 0
+	}
+
+	sampledIn, sampledOut := 0, 0
+	for n := range 100 {
+		traceID := fmt.Sprintf("trace-%d", n)
+		first := logged(traceID)
+		for range 5 {
+			if logged(traceID) != first {
+				t.Fatalf("expected consistent sampling decision for %s", traceID)
+			}
+		}
+		if first {
+			sampledIn++
+		} else {
+			sampledOut++
+		}
+	}
+	if sampledIn == 0 || sampledOut == 0 {
+		t.Errorf("expected keys to be split by the sampling rate, got %d in and %d out", sampledIn, sampledOut)
+	}
+}
+
+func TestWithSampleRates(t *testing.T) {
+	logger, buf := newTestLogger(slog.LevelInfo)
+	interceptor := NewLoggingInterceptor(
+		WithLogger(logger),
+		WithSampleRates(map[connect.Code]float64{
+			0:                           0.1,
+			connect.CodeNotFound:        0.5,
+			connect.CodeInvalidArgument: 0,
+		}, 1),
+	)
+
+	const calls = 2000
+	counts := make(map[string]int)
+	for _, code := range []connect.Code{0, connect.CodeNotFound, connect.CodeInvalidArgument, connect.CodeInternal} {
+		handler := interceptor.WrapUnary(func(context.Context, connect.AnyRequest) (connect.AnyResponse, error) {
+			if code == 0 {
+				return connect.NewResponse(&struct{}{}), nil
+			}
+			return nil, connect.NewError(code, nil)
+		})
+		for range calls {
+			_, _ = handler(context.Background(), newTestRequest(testProcedure, &struct{}{}))
+		}
+	}
+	for _, record := range logRecords(t, buf) {
+		counts[recordCode(record)]++
+	}
+
+	tests := []struct {
+		code     string
+		min, max int
+	}{
+		{code: "ok", min: 100, max: 300},
+		{code: "not_found", min: 850, max: 1150},
+		{code: "invalid_argument", min: 0, max: 0},
+		{code: "internal", min: calls, max: calls},
+	}
+	for _, tt := range tests {
+		if got := counts[tt.code]; got < tt.min || got > tt.max {
+			t.Errorf("%s: expected %d..%d logged calls, got %d", tt.code, tt.min, tt.max, got)
+		}
+	}
+}
+
+func TestWithErrorSampling(t *testing.T) {
+	logger, buf := newTestLogger(slog.LevelInfo)
+	interceptor := NewLoggingInterceptor(
+		WithLogger(logger),
+		WithErrorSampling(map[connect.Code]float64{connect.CodeInvalidArgument: 0.1}),
+	)
+
+	const calls = 2000
+	for _, code := range []connect.Code{0, connect.CodeInvalidArgument, connect.CodeInternal} {
+		handler := interceptor.WrapUnary(func(context.Context, connect.AnyRequest) (connect.AnyResponse, error) {
+			if code == 0 {
+				return connect.NewResponse(&struct{}{}), nil
+			}
+			return nil, connect.NewError(code, nil)
+		})
+		for range calls {
+			_, _ = handler(context.Background(), newTestRequest(testProcedure, &struct{}{}))
+		}
+	}
+
+	counts := make(map[string]int)
+	for _, record := range logRecords(t, buf) {
+		counts[recordCode(record)]++
+	}
+
+	tests := []struct {
+		code     string
+		min, max int
+	}{
+		{code: "ok", min: calls, max: calls},
+		{code: "invalid_argument", min: 100, max: 300},
+		{code: "internal", min: calls, max: calls},
+	}
+	for _, tt := range tests {
+		if got := counts[tt.code]; got < tt.min || got > tt.max {
+			t.Errorf("%s: expected %d..%d logged calls, got %d", tt.code, tt.min, tt.max, got)
+		}
+	}
+}
+
+func TestWithErrorSamplingCopiesRates(t *testing.T) {
+	rates := map[connect.Code]float64{connect.CodeInvalidArgument: 0.1}
+	var options Options
+	WithErrorSampling(rates)(&options)
+
+	rates[connect.CodeInvalidArgument] = 0
+	if got := options.ErrorSampling[connect.CodeInvalidArgument]; got != 0.1 {
+		t.Errorf("expected the rates to be copied, got %v", got)
+	}
+}
+
+// recordCode returns the code of a completion record: code on success and
+// error.code on failure.
+func recordCode(record map[string]any) string {
+	if code, ok := record["code"].(string); ok {
+		return code
+	}
+	errGroup, _ := record["error"].(map[string]any)
+	code, _ := errGroup["code"].(string)
+	return code
+}
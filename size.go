@@ -1,8 +1,12 @@
 package connectlog
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
+	"log/slog"
 
+	"google.golang.org/protobuf/encoding/protojson"
 	"google.golang.org/protobuf/proto"
 )
 
@@ -27,3 +31,50 @@ func calculateSize(payload any) int {
 		return -1 // Unknown type
 	}
 }
+
+// appendCodecSizes adds a key+"_sizes" group with the binary (proto_size)
+// and protojson (json_size) encoded sizes of proto messages when
+// WithLogCodecSizes is enabled. Other payloads are left out.
+func (i *LoggingInterceptor) appendCodecSizes(attrs []any, key string, payload any) []any {
+	msg, ok := payload.(proto.Message)
+	if !i.options.LogCodecSizes || !ok {
+		return attrs
+	}
+
+	data, err := protojson.Marshal(msg)
+	if err != nil {
+		return attrs
+	}
+	return append(attrs, slog.Group(key+"_sizes",
+		slog.Int("proto_size", proto.Size(msg)),
+		slog.Int("json_size", len(data)),
+	))
+}
+
+// marshalPayload returns the wire representation of a payload: the
+// deterministic proto encoding for proto messages, the raw bytes for byte
+// and string payloads, and the JSON encoding for everything else.
+func marshalPayload(payload any) ([]byte, bool) {
+	switch v := payload.(type) {
+	case nil:
+		return nil, false
+	case proto.Message:
+		data, err := proto.MarshalOptions{Deterministic: true}.Marshal(v)
+		return data, err == nil
+	case []byte:
+		return v, true
+	case string:
+		return []byte(v), true
+	case json.RawMessage:
+		return v, true
+	default:
+		data, err := json.Marshal(v)
+		return data, err == nil
+	}
+}
+
+// hashPayload returns the hex-encoded SHA-256 hash of data.
+func hashPayload(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
@@ -2,7 +2,12 @@ package connectlog
 
 import (
 	"context"
+	"errors"
+	"io"
 	"log/slog"
+	"sync"
+	"sync/atomic"
+	"time"
 
 	"connectrpc.com/connect"
 )
@@ -10,50 +15,313 @@ import (
 // loggedStreamConn wraps a streaming connection to track and log messages
 type loggedStreamConn struct {
 	connect.StreamingHandlerConn
+	interceptor   *LoggingInterceptor
 	logger        *slog.Logger
 	ctx           context.Context
 	sentCount     int
 	receivedCount int
-	debugEnabled  bool
+	batch         []streamMessage
+	caller        string
+	active        time.Duration // time spent inside Send and Receive
+
+	// Message counts when the first Send or Receive error occurred
+	failed         bool
+	failedSent     int
+	failedReceived int
+
+	// Messages and bytes since the last WithStreamMessageSummaryInterval
+	// summary, updated by Send/Receive and reset together by the ticker
+	// goroutine, so that both counts describe the same window
+	summaryMu       sync.Mutex
+	summaryMessages int64
+	summaryBytes    int64
+	stopSummaries   func()
+
+	// Stops watching for a client disconnect; see logDisconnect
+	stopWatching func() bool
+	disconnected chan struct{}
+
+	// WithStreamIdleWarning state; see checkIdle
+	lastActivity atomic.Int64 // unix nanoseconds
+	idleWarned   atomic.Bool
+	idleMu       sync.Mutex // serializes checkIdle with stopIdle
+	idleTimer    *time.Timer
+	idleStopped  bool
+
+	doneOnce sync.Once
 }
 
-func newLoggedStreamConn(ctx context.Context, conn connect.StreamingHandlerConn, logger *slog.Logger) *loggedStreamConn {
-	return &loggedStreamConn{
+// streamMessage describes a single stream message in a batched debug log.
+type streamMessage struct {
+	Direction string `json:"direction"`
+	Number    int    `json:"number"`
+	Size      int    `json:"size,omitempty"`
+}
+
+func newLoggedStreamConn(ctx context.Context, conn connect.StreamingHandlerConn, logger *slog.Logger, interceptor *LoggingInterceptor) *loggedStreamConn {
+	c := &loggedStreamConn{
 		StreamingHandlerConn: conn,
+		interceptor:          interceptor,
 		logger:               logger,
 		ctx:                  ctx,
-		debugEnabled:         logger.Enabled(ctx, slog.LevelDebug),
+		disconnected:         make(chan struct{}),
+	}
+	c.stopWatching = context.AfterFunc(ctx, c.logDisconnect) // released by done
+	if interceptor.options.StreamIdleWarning > 0 {
+		c.lastActivity.Store(time.Now().UnixNano())
+		c.idleTimer = time.AfterFunc(interceptor.options.StreamIdleWarning, c.checkIdle)
+	}
+	return c
+}
+
+// touch records message activity for WithStreamIdleWarning.
+func (c *loggedStreamConn) touch() {
+	if c.idleTimer != nil {
+		c.lastActivity.Store(time.Now().UnixNano())
+		c.idleWarned.Store(false)
+	}
+}
+
+// checkIdle runs on the idle timer. It warns once per idle period when no
+// message was sent or received for the threshold, and reschedules itself
+// for the moment the threshold would next be reached. Only checkIdle resets
+// the timer, so message activity never races with it.
+func (c *loggedStreamConn) checkIdle() {
+	c.idleMu.Lock()
+	defer c.idleMu.Unlock()
+	if c.idleStopped {
+		return
+	}
+
+	threshold := c.interceptor.options.StreamIdleWarning
+	idle := time.Since(time.Unix(0, c.lastActivity.Load()))
+	if idle < threshold {
+		c.idleTimer.Reset(threshold - idle)
+		return
+	}
+	if !c.idleWarned.Swap(true) {
+		c.logger.WarnContext(c.ctx, "stream idle", slog.Duration("idle", idle))
 	}
+	c.idleTimer.Reset(threshold)
+}
+
+// stopIdle stops the idle timer, waiting for a running check to finish.
+func (c *loggedStreamConn) stopIdle() {
+	if c.idleTimer == nil {
+		return
+	}
+	c.idleMu.Lock()
+	defer c.idleMu.Unlock()
+	c.idleStopped = true
+	c.idleTimer.Stop()
+}
+
+// logDisconnect logs the moment the client canceled the stream, rather
+// than only when the handler returns. Deadlines and cancellations caused by
+// Shutdown are not client disconnects.
+func (c *loggedStreamConn) logDisconnect() {
+	defer close(c.disconnected)
+	if errors.Is(c.ctx.Err(), context.Canceled) && !c.interceptor.shuttingDown.Load() {
+		c.logger.DebugContext(c.ctx, "client disconnected")
+	}
+}
+
+// done ends the stream: it stops watching for a client disconnect, waiting
+// for a disconnect being logged, stops the idle timer and flushes the
+// pending message logs. Only the first call has an effect, so it can also be
+// deferred to clean up after a panicking handler.
+func (c *loggedStreamConn) done() {
+	c.doneOnce.Do(func() {
+		if !c.stopWatching() {
+			<-c.disconnected
+		}
+		c.stopIdle()
+		c.flushMessages()
+	})
+}
+
+// debugEnabled reports whether per-message debug logs are enabled. It is
+// checked for every message so that level changes (e.g. via slog.LevelVar)
+// take effect on long-lived streams.
+func (c *loggedStreamConn) debugEnabled() bool {
+	return c.logger.Enabled(c.ctx, slog.LevelDebug)
 }
 
 func (c *loggedStreamConn) Send(msg any) error {
-	if err := c.StreamingHandlerConn.Send(msg); err != nil {
+	c.captureCaller()
+	start := time.Now()
+	err := c.StreamingHandlerConn.Send(msg)
+	elapsed := time.Since(start)
+	c.active += elapsed
+	if err != nil {
+		c.recordFailure(err)
 		return err
 	}
+	c.touch()
 	c.sentCount++
-	if c.debugEnabled {
-		c.logger.Debug("stream message sent",
-			slog.Int("number", c.sentCount),
-			slog.Int("size", calculateSize(msg)),
-			slog.Any("response", msg),
-		)
-	}
+	c.checkSlowMessage("sent", c.sentCount, elapsed, msg)
+	c.logMessage("sent", c.sentCount, "response", c.interceptor.loggedResponse(msg))
 	return nil
 }
 
 func (c *loggedStreamConn) Receive(msg any) error {
-	if err := c.StreamingHandlerConn.Receive(msg); err != nil {
+	c.captureCaller()
+	start := time.Now()
+	err := c.StreamingHandlerConn.Receive(msg)
+	elapsed := time.Since(start)
+	c.active += elapsed
+	if err != nil {
+		c.recordFailure(err)
 		return err
 	}
+	c.touch()
 
 	c.receivedCount++
-	if c.debugEnabled {
-		c.logger.Debug("stream message received",
-			slog.Int("number", c.receivedCount),
-			slog.Int("size", calculateSize(msg)),
-			slog.Any("receive", msg),
-		)
-	}
+	c.checkSlowMessage("received", c.receivedCount, elapsed, msg)
+	c.logMessage("received", c.receivedCount, "receive", msg)
 
 	return nil
 }
+
+// recordFailure keeps the message counts at the first Send or Receive
+// error. The io.EOF ending the received messages is not a failure.
+func (c *loggedStreamConn) recordFailure(err error) {
+	if !c.failed && !errors.Is(err, io.EOF) {
+		c.failed = true
+		c.failedSent, c.failedReceived = c.sentCount, c.receivedCount
+	}
+}
+
+// captureCaller records the handler code sending or receiving the first
+// message when WithCallerInfo is enabled.
+func (c *loggedStreamConn) captureCaller() {
+	if c.interceptor.options.CallerInfo && c.caller == "" {
+		c.caller = callerInfo()
+	}
+}
+
+// logMessage writes the debug log for a sent or received message, or adds
+// it to the current batch when WithStreamMessageBatch is enabled.
+func (c *loggedStreamConn) logMessage(direction string, number int, bodyKey string, msg any) {
+	if !c.debugEnabled() {
+		return
+	}
+
+	if c.interceptor.options.StreamSummaryInterval > 0 {
+		var size int64
+		if c.interceptor.options.StreamMessageSizes {
+			size = int64(calculateSize(msg))
+		}
+		c.summaryMu.Lock()
+		c.summaryMessages++
+		c.summaryBytes += size
+		c.summaryMu.Unlock()
+		return
+	}
+
+	if c.interceptor.options.StreamMessageBatch > 1 {
+		message := streamMessage{Direction: direction, Number: number}
+		if c.interceptor.options.StreamMessageSizes {
+			message.Size = calculateSize(msg)
+		}
+		c.batch = append(c.batch, message)
+		if len(c.batch) >= c.interceptor.options.StreamMessageBatch {
+			c.flushMessages()
+		}
+		return
+	}
+
+	attrs := []any{slog.Int("number", number)}
+	if c.interceptor.options.StreamMessageSizes {
+		attrs = append(attrs, slog.Int("size", calculateSize(msg)))
+	}
+	attrs = append(attrs, c.interceptor.bodyAttr(bodyKey, msg))
+	attrs = c.interceptor.appendCodecSizes(attrs, bodyKey, msg)
+	c.logger.DebugContext(c.ctx, "stream message "+direction, attrs...)
+}
+
+// startSummaries starts the goroutine logging a summary of the messages
+// exchanged every WithStreamMessageSummaryInterval. It is a no-op when the
+// option is disabled.
+func (c *loggedStreamConn) startSummaries() {
+	interval := c.interceptor.options.StreamSummaryInterval
+	if interval <= 0 {
+		return
+	}
+
+	done, stopped := make(chan struct{}), make(chan struct{})
+	c.stopSummaries = func() {
+		close(done)
+		<-stopped
+	}
+	c.interceptor.goBackground(func(ctx context.Context) {
+		defer close(stopped)
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				c.logSummary()
+			case <-done:
+				return
+			case <-ctx.Done():
+				return
+			}
+		}
+	})
+}
+
+// logSummary writes the number and total size of the messages exchanged
+// since the previous summary. Nothing is logged for idle intervals.
+func (c *loggedStreamConn) logSummary() {
+	c.summaryMu.Lock()
+	messages, bytes := c.summaryMessages, c.summaryBytes
+	c.summaryMessages, c.summaryBytes = 0, 0
+	c.summaryMu.Unlock()
+
+	if messages == 0 {
+		return
+	}
+	attrs := []any{slog.Int64("messages", messages)}
+	if c.interceptor.options.StreamMessageSizes {
+		attrs = append(attrs, slog.Int64("bytes", bytes))
+	}
+	c.logger.DebugContext(c.ctx, "stream messages summary", attrs...)
+}
+
+// flushMessages writes the batched message descriptions or the final
+// summary, if any.
+func (c *loggedStreamConn) flushMessages() {
+	if c.stopSummaries != nil {
+		c.stopSummaries()
+		c.logSummary()
+		return
+	}
+	if len(c.batch) == 0 {
+		return
+	}
+	c.logger.DebugContext(c.ctx, "stream messages", slog.Any("messages", c.batch))
+	c.batch = nil
+}
+
+// checkSlowMessage logs a warning when sending or receiving a single message
+// took longer than the configured threshold. A truncated sample of the
+// message is included when debug logging or WithSlowMessageSample is enabled.
+func (c *loggedStreamConn) checkSlowMessage(direction string, number int, elapsed time.Duration, msg any) {
+	threshold := c.interceptor.options.SlowMessageThreshold
+	if threshold <= 0 || elapsed < threshold {
+		return
+	}
+
+	attrs := []any{
+		slog.String("direction", direction),
+		slog.Int("number", number),
+		slog.Duration("duration", elapsed),
+	}
+	if c.interceptor.options.SlowMessageSample || c.debugEnabled() {
+		attrs = append(attrs, slog.String("sample", c.interceptor.payloadSample(msg)))
+	}
+
+	c.logger.WarnContext(c.ctx, "slow stream message", attrs...)
+}
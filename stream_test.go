@@ -0,0 +1,613 @@
+package connectlog
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"runtime"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"connectrpc.com/connect"
+	"google.golang.org/protobuf/types/known/typepb"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+func TestWithSlowMessageThreshold(t *testing.T) {
+	tests := []struct {
+		name   string
+		sample bool
+	}{
+		{name: "without sample"},
+		{name: "with sample", sample: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logger, buf := newTestLogger(slog.LevelInfo)
+			interceptor := NewLoggingInterceptor(
+				WithLogger(logger),
+				WithSlowMessageThreshold(5*time.Millisecond),
+				WithSlowMessageSample(tt.sample),
+			)
+
+			conn := newTestStreamConn(connect.StreamTypeServer, 0)
+			conn.sendDelay = 10 * time.Millisecond
+			handler := interceptor.WrapStreamingHandler(func(_ context.Context, conn connect.StreamingHandlerConn) error {
+				return conn.Send(wrapperspb.String("slow payload"))
+			})
+			if err := handler(context.Background(), conn); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			record := findRecord(t, logRecords(t, buf), "slow stream message")
+			if got := record[slog.LevelKey]; got != "WARN" {
+				t.Errorf("expected WARN, got %v", got)
+			}
+			if got := record["direction"]; got != "sent" {
+				t.Errorf("expected direction sent, got %v", got)
+			}
+
+			sample, ok := record["sample"].(string)
+			if ok != tt.sample {
+				t.Fatalf("expected sample present=%v, got %v", tt.sample, record["sample"])
+			}
+			if tt.sample && !strings.Contains(sample, "slow payload") {
+				t.Errorf("expected sample to contain the message, got %q", sample)
+			}
+		})
+	}
+}
+
+func TestWithSkipEmptyStreams(t *testing.T) {
+	tests := []struct {
+		name     string
+		skip     bool
+		incoming int
+		logged   bool
+	}{
+		{name: "empty stream skipped", skip: true, logged: false},
+		{name: "non-empty stream logged", skip: true, incoming: 1, logged: true},
+		{name: "empty stream logged by default", skip: false, logged: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logger, buf := newTestLogger(slog.LevelInfo)
+			interceptor := NewLoggingInterceptor(WithLogger(logger), WithSkipEmptyStreams(tt.skip))
+
+			handler := interceptor.WrapStreamingHandler(func(_ context.Context, conn connect.StreamingHandlerConn) error {
+				for range tt.incoming {
+					if err := conn.Receive(&struct{}{}); err != nil {
+						return err
+					}
+				}
+				return nil
+			})
+			_ = handler(context.Background(), newTestStreamConn(connect.StreamTypeBidi, tt.incoming))
+
+			if got := len(logRecords(t, buf)) > 0; got != tt.logged {
+				t.Errorf("expected logged %v, got %v", tt.logged, got)
+			}
+		})
+	}
+}
+
+type byteCounterKey struct{}
+
+type fakeByteCounter struct {
+	in, out int64
+}
+
+func TestWithByteCounter(t *testing.T) {
+	logger, buf := newTestLogger(slog.LevelInfo)
+	interceptor := NewLoggingInterceptor(WithLogger(logger), WithByteCounter(func(ctx context.Context) (int64, int64, bool) {
+		counter, ok := ctx.Value(byteCounterKey{}).(*fakeByteCounter)
+		if !ok {
+			return 0, 0, false
+		}
+		return counter.in, counter.out, true
+	}))
+
+	counter := &fakeByteCounter{}
+	ctx := context.WithValue(context.Background(), byteCounterKey{}, counter)
+	handler := interceptor.WrapStreamingHandler(func(context.Context, connect.StreamingHandlerConn) error {
+		counter.in, counter.out = 1024, 2048
+		return nil
+	})
+	_ = handler(ctx, newTestStreamConn(connect.StreamTypeBidi, 0))
+
+	record := findRecord(t, logRecords(t, buf), "stream completed")
+	if got := record["wire_bytes_in"]; got != float64(1024) {
+		t.Errorf("expected wire_bytes_in 1024, got %v", got)
+	}
+	if got := record["wire_bytes_out"]; got != float64(2048) {
+		t.Errorf("expected wire_bytes_out 2048, got %v", got)
+	}
+}
+
+func TestStreamDebugLevelChange(t *testing.T) {
+	var level slog.LevelVar
+	level.Set(slog.LevelInfo)
+
+	logger, buf := newTestLogger(&level)
+	interceptor := NewLoggingInterceptor(WithLogger(logger))
+
+	handler := interceptor.WrapStreamingHandler(func(_ context.Context, conn connect.StreamingHandlerConn) error {
+		for n := range 4 {
+			if n == 2 {
+				level.Set(slog.LevelDebug)
+			}
+			if err := conn.Send(&struct{}{}); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	_ = handler(context.Background(), newTestStreamConn(connect.StreamTypeServer, 0))
+
+	var numbers []float64
+	for _, record := range logRecords(t, buf) {
+		if record[slog.MessageKey] == "stream message sent" {
+			numbers = append(numbers, record["number"].(float64))
+		}
+	}
+	if len(numbers) != 2 || numbers[0] != 3 || numbers[1] != 4 {
+		t.Errorf("expected messages 3 and 4 to be logged after enabling debug, got %v", numbers)
+	}
+}
+
+func TestWithStreamMessageBatch(t *testing.T) {
+	logger, buf := newTestLogger(slog.LevelDebug)
+	interceptor := NewLoggingInterceptor(WithLogger(logger), WithStreamMessageBatch(10))
+
+	conn := newTestStreamConn(connect.StreamTypeServer, 0)
+	handler := interceptor.WrapStreamingHandler(func(_ context.Context, conn connect.StreamingHandlerConn) error {
+		for range 25 {
+			if err := conn.Send(wrapperspb.String("item")); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err := handler(context.Background(), conn); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var batches []int
+	for _, record := range logRecords(t, buf) {
+		switch record[slog.MessageKey] {
+		case "stream message sent":
+			t.Error("expected no per-message logs in batch mode")
+		case "stream messages":
+			messages, _ := record["messages"].([]any)
+			batches = append(batches, len(messages))
+		}
+	}
+
+	if len(batches) != 3 || batches[0] != 10 || batches[1] != 10 || batches[2] != 5 {
+		t.Errorf("expected batches of 10, 10 and 5 messages, got %v", batches)
+	}
+}
+
+func TestStreamCompletionCode(t *testing.T) {
+	tests := []struct {
+		name     string
+		err      error
+		expected string
+	}{
+		{name: "success", expected: "ok"},
+		{name: "failure", err: connect.NewError(connect.CodeUnavailable, nil), expected: "unavailable"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logger, buf := newTestLogger(slog.LevelInfo)
+			interceptor := NewLoggingInterceptor(WithLogger(logger))
+
+			handler := interceptor.WrapStreamingHandler(func(context.Context, connect.StreamingHandlerConn) error {
+				return tt.err
+			})
+			_ = handler(context.Background(), newTestStreamConn(connect.StreamTypeBidi, 0))
+
+			records := logRecords(t, buf)
+			if len(records) != 1 {
+				t.Fatalf("expected 1 record, got %d", len(records))
+			}
+			if got := recordCode(records[0]); got != tt.expected {
+				t.Errorf("expected code %q, got %v", tt.expected, got)
+			}
+			if _, ok := records[0]["code"]; ok && tt.err != nil {
+				t.Error("expected the failure code only in the error group")
+			}
+		})
+	}
+}
+
+func TestStreamFailedAt(t *testing.T) {
+	t.Run("send failure", func(t *testing.T) {
+		logger, buf := newTestLogger(slog.LevelInfo)
+		interceptor := NewLoggingInterceptor(WithLogger(logger))
+
+		// The handler keeps receiving after the second send fails
+		handler := interceptor.WrapStreamingHandler(func(_ context.Context, conn connect.StreamingHandlerConn) error {
+			for range 3 {
+				if err := conn.Receive(&struct{}{}); err != nil {
+					return err
+				}
+			}
+			_ = conn.Send(wrapperspb.String("partial"))
+			sendErr := conn.Send(wrapperspb.String("lost"))
+			for conn.Receive(&struct{}{}) == nil {
+			}
+			return sendErr
+		})
+		stream := newTestStreamConn(connect.StreamTypeBidi, 5)
+		stream.sendLimit = 1
+		_ = handler(context.Background(), stream)
+
+		record := findRecord(t, logRecords(t, buf), "stream failed")
+		if got := record["failed_at_sent"]; got != float64(1) {
+			t.Errorf("expected failed_at_sent 1, got %v", got)
+		}
+		if got := record["failed_at_received"]; got != float64(3) {
+			t.Errorf("expected failed_at_received 3, got %v", got)
+		}
+		if messages, _ := record["messages"].(map[string]any); messages["received"] != float64(5) {
+			t.Errorf("expected 5 received messages, got %v", record["messages"])
+		}
+		if got := record["sent_any"]; got != true {
+			t.Errorf("expected sent_any true, got %v", got)
+		}
+	})
+
+	t.Run("handler failure", func(t *testing.T) {
+		logger, buf := newTestLogger(slog.LevelInfo)
+		interceptor := NewLoggingInterceptor(WithLogger(logger))
+
+		handler := interceptor.WrapStreamingHandler(func(_ context.Context, conn connect.StreamingHandlerConn) error {
+			for conn.Receive(&struct{}{}) == nil {
+			}
+			return connect.NewError(connect.CodeInternal, errors.New("broken"))
+		})
+		_ = handler(context.Background(), newTestStreamConn(connect.StreamTypeClient, 3))
+
+		// The messages group already holds the counts at the failure
+		record := findRecord(t, logRecords(t, buf), "stream failed")
+		if _, ok := record["failed_at_received"]; ok {
+			t.Errorf("expected no failed_at counts without a send or receive error, got %v", record)
+		}
+		if got := record["sent_any"]; got != false {
+			t.Errorf("expected sent_any false, got %v", got)
+		}
+	})
+}
+
+func TestStreamFailedBeforeSend(t *testing.T) {
+	logger, buf := newTestLogger(slog.LevelInfo)
+	interceptor := NewLoggingInterceptor(WithLogger(logger))
+
+	handler := interceptor.WrapStreamingHandler(func(context.Context, connect.StreamingHandlerConn) error {
+		return connect.NewError(connect.CodeFailedPrecondition, errors.New("not ready"))
+	})
+	_ = handler(context.Background(), newTestStreamConn(connect.StreamTypeServer, 1))
+
+	record := findRecord(t, logRecords(t, buf), "stream failed")
+	if got := record["sent_any"]; got != false {
+		t.Errorf("expected sent_any false, got %v", got)
+	}
+}
+
+func TestStreamActiveDuration(t *testing.T) {
+	logger, buf := newTestLogger(slog.LevelInfo)
+	interceptor := NewLoggingInterceptor(WithLogger(logger))
+
+	conn := newTestStreamConn(connect.StreamTypeServer, 0)
+	conn.sendDelay = 5 * time.Millisecond
+	handler := interceptor.WrapStreamingHandler(func(_ context.Context, conn connect.StreamingHandlerConn) error {
+		if err := conn.Send(wrapperspb.String("first")); err != nil {
+			return err
+		}
+		time.Sleep(30 * time.Millisecond) // idle between messages
+		return conn.Send(wrapperspb.String("second"))
+	})
+	if err := handler(context.Background(), conn); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	record := findRecord(t, logRecords(t, buf), "stream completed")
+	active, _ := record["active_duration"].(float64)
+	total, _ := record["duration"].(float64)
+	if active < float64(10*time.Millisecond) {
+		t.Errorf("expected active duration to cover both sends, got %v", time.Duration(active))
+	}
+	if total-active < float64(30*time.Millisecond) {
+		t.Errorf("expected idle time to be excluded from active duration, got active %v of %v",
+			time.Duration(active), time.Duration(total))
+	}
+}
+
+func TestWithStreamMessageSummaryInterval(t *testing.T) {
+	logger, buf := newTestLogger(slog.LevelDebug)
+	// The interval is never reached: the first tick is simulated by the handler
+	interceptor := NewLoggingInterceptor(WithLogger(logger), WithStreamMessageSummaryInterval(time.Hour))
+	defer interceptor.Close()
+
+	msg := wrapperspb.String("item")
+	conn := newTestStreamConn(connect.StreamTypeServer, 0)
+	handler := interceptor.WrapStreamingHandler(func(_ context.Context, conn connect.StreamingHandlerConn) error {
+		for range 2 {
+			if err := conn.Send(msg); err != nil {
+				return err
+			}
+		}
+		conn.(*loggedStreamConn).logSummary()
+		conn.(*loggedStreamConn).logSummary() // idle interval
+		for range 3 {
+			if err := conn.Send(msg); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err := handler(context.Background(), conn); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var summaries [][2]float64
+	for _, record := range logRecords(t, buf) {
+		switch record[slog.MessageKey] {
+		case "stream message sent":
+			t.Error("expected no per-message logs in summary mode")
+		case "stream messages summary":
+			messages, _ := record["messages"].(float64)
+			bytes, _ := record["bytes"].(float64)
+			summaries = append(summaries, [2]float64{messages, bytes})
+		}
+	}
+
+	size := float64(calculateSize(msg))
+	expected := [][2]float64{{2, 2 * size}, {3, 3 * size}}
+	if !slices.Equal(summaries, expected) {
+		t.Errorf("expected summaries %v, got %v", expected, summaries)
+	}
+}
+
+func TestWithStreamMessageSummaryIntervalPanic(t *testing.T) {
+	logger, _ := newTestLogger(slog.LevelDebug)
+	interceptor := NewLoggingInterceptor(WithLogger(logger), WithStreamMessageSummaryInterval(time.Hour))
+	defer interceptor.Close()
+
+	handler := interceptor.WrapStreamingHandler(func(context.Context, connect.StreamingHandlerConn) error {
+		panic("boom")
+	})
+	before := runtime.NumGoroutine()
+	for range 50 {
+		func() {
+			defer func() { _ = recover() }()
+			_ = handler(context.Background(), newTestStreamConn(connect.StreamTypeServer, 0))
+		}()
+	}
+
+	// The summary goroutines end with their panicking streams
+	deadline := time.Now().Add(time.Second)
+	for runtime.NumGoroutine() > before && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	if got := runtime.NumGoroutine(); got > before {
+		t.Errorf("expected at most %d goroutines, got %d", before, got)
+	}
+}
+
+func TestWithStreamMessageSizes(t *testing.T) {
+	for _, enabled := range []bool{true, false} {
+		logger, buf := newTestLogger(slog.LevelDebug)
+		interceptor := NewLoggingInterceptor(WithLogger(logger), WithStreamMessageSizes(enabled))
+
+		conn := newTestStreamConn(connect.StreamTypeServer, 0)
+		handler := interceptor.WrapStreamingHandler(func(_ context.Context, conn connect.StreamingHandlerConn) error {
+			return conn.Send(wrapperspb.String("item"))
+		})
+		if err := handler(context.Background(), conn); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		_, ok := findRecord(t, logRecords(t, buf), "stream message sent")["size"]
+		if ok != enabled {
+			t.Errorf("sizes %v: expected size logged %v, got %v", enabled, enabled, ok)
+		}
+	}
+}
+
+func BenchmarkStreamMessageSizes(b *testing.B) {
+	msg := &typepb.Type{Name: "acme.User", Oneofs: []string{"contact"}}
+	for n := range 50 {
+		msg.Fields = append(msg.Fields, &typepb.Field{Name: "field_" + strconv.Itoa(n), Number: int32(n + 1)})
+	}
+
+	for _, enabled := range []bool{true, false} {
+		b.Run("sizes="+strconv.FormatBool(enabled), func(b *testing.B) {
+			logger := slog.New(slog.NewJSONHandler(io.Discard, &slog.HandlerOptions{Level: slog.LevelDebug}))
+			interceptor := NewLoggingInterceptor(WithLogger(logger),
+				WithStreamMessageSummaryInterval(time.Hour),
+				WithStreamMessageSizes(enabled))
+			defer interceptor.Close()
+
+			conn := newLoggedStreamConn(context.Background(), newTestStreamConn(connect.StreamTypeServer, 0), logger, interceptor)
+			b.ReportAllocs()
+			for b.Loop() {
+				_ = conn.Send(msg)
+			}
+		})
+	}
+}
+
+func TestClientDisconnected(t *testing.T) {
+	tests := []struct {
+		name     string
+		cancel   func(context.Context) (context.Context, func())
+		expected bool
+	}{
+		{
+			name: "client canceled",
+			cancel: func(ctx context.Context) (context.Context, func()) {
+				ctx, cancel := context.WithCancel(ctx)
+				return ctx, cancel
+			},
+			expected: true,
+		},
+		{
+			name: "deadline exceeded",
+			cancel: func(ctx context.Context) (context.Context, func()) {
+				ctx, cancel := context.WithTimeout(ctx, time.Millisecond)
+				return ctx, func() {
+					<-ctx.Done()
+					cancel()
+				}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logger, buf := newTestLogger(slog.LevelDebug)
+			interceptor := NewLoggingInterceptor(WithLogger(logger))
+
+			ctx, cancel := tt.cancel(context.Background())
+			conn := newTestStreamConn(connect.StreamTypeServer, 0)
+			handler := interceptor.WrapStreamingHandler(func(ctx context.Context, conn connect.StreamingHandlerConn) error {
+				if err := conn.Send(wrapperspb.String("item")); err != nil {
+					return err
+				}
+				cancel()
+				return connect.NewError(connect.CodeCanceled, ctx.Err())
+			})
+			_ = handler(ctx, conn)
+
+			records := logRecords(t, buf)
+			disconnected := slices.IndexFunc(records, func(record map[string]any) bool {
+				return record[slog.MessageKey] == "client disconnected"
+			})
+			if got := disconnected >= 0; got != tt.expected {
+				t.Fatalf("expected client disconnected logged %v, got %v", tt.expected, got)
+			}
+			if tt.expected && disconnected != len(records)-2 {
+				t.Errorf("expected the disconnect before the completion log, got %v", records)
+			}
+		})
+	}
+}
+
+func TestStreamClientDisconnectAfterPanic(t *testing.T) {
+	logger, buf := newTestLogger(slog.LevelDebug)
+	interceptor := NewLoggingInterceptor(WithLogger(logger))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	handler := interceptor.WrapStreamingHandler(func(context.Context, connect.StreamingHandlerConn) error {
+		panic("boom")
+	})
+	func() {
+		defer func() { _ = recover() }()
+		_ = handler(ctx, newTestStreamConn(connect.StreamTypeServer, 0))
+	}()
+
+	// Canceling the context of a stream that already ended is no disconnect
+	cancel()
+	time.Sleep(10 * time.Millisecond)
+	for _, record := range logRecords(t, buf) {
+		if record[slog.MessageKey] == "client disconnected" {
+			t.Fatalf("expected no disconnect after the stream ended, got %v", record)
+		}
+	}
+}
+
+func TestWithSlowStreamThreshold(t *testing.T) {
+	tests := []struct {
+		name     string
+		opts     []Option
+		expected bool
+	}{
+		{name: "under stream threshold", opts: []Option{WithSlowThreshold(time.Millisecond), WithSlowStreamThreshold(time.Hour)}},
+		{name: "unary threshold only", opts: []Option{WithSlowThreshold(time.Millisecond)}},
+		{name: "over stream threshold", opts: []Option{WithSlowStreamThreshold(time.Millisecond)}, expected: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logger, buf := newTestLogger(slog.LevelInfo)
+			interceptor := NewLoggingInterceptor(append(tt.opts, WithLogger(logger))...)
+
+			conn := newTestStreamConn(connect.StreamTypeServer, 0)
+			handler := interceptor.WrapStreamingHandler(func(context.Context, connect.StreamingHandlerConn) error {
+				time.Sleep(5 * time.Millisecond)
+				return nil
+			})
+			if err := handler(context.Background(), conn); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			_, slow := findRecord(t, logRecords(t, buf), "stream completed")["slow"]
+			if slow != tt.expected {
+				t.Errorf("expected slow %v, got %v", tt.expected, slow)
+			}
+		})
+	}
+}
+
+func TestWithStreamIdleWarning(t *testing.T) {
+	logger, buf := newTestLogger(slog.LevelInfo)
+	interceptor := NewLoggingInterceptor(WithLogger(logger), WithStreamIdleWarning(10*time.Millisecond))
+
+	conn := newTestStreamConn(connect.StreamTypeBidi, 1)
+	handler := interceptor.WrapStreamingHandler(func(_ context.Context, conn connect.StreamingHandlerConn) error {
+		if err := conn.Receive(&struct{}{}); err != nil {
+			return err
+		}
+		time.Sleep(50 * time.Millisecond) // a single idle gap
+		return conn.Send(wrapperspb.String("late"))
+	})
+	if err := handler(context.Background(), conn); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var warnings []map[string]any
+	for _, record := range logRecords(t, buf) {
+		if record[slog.MessageKey] == "stream idle" {
+			warnings = append(warnings, record)
+		}
+	}
+	if len(warnings) != 1 {
+		t.Fatalf("expected one idle warning for the gap, got %d", len(warnings))
+	}
+	if idle, _ := warnings[0]["idle"].(float64); idle < float64(10*time.Millisecond) {
+		t.Errorf("expected idle of at least 10ms, got %v", warnings[0]["idle"])
+	}
+	if warnings[0][slog.LevelKey] != slog.LevelWarn.String() {
+		t.Errorf("expected a warning, got %v", warnings[0][slog.LevelKey])
+	}
+}
+
+func TestWithStreamIdleWarningPanic(t *testing.T) {
+	logger, buf := newTestLogger(slog.LevelInfo)
+	interceptor := NewLoggingInterceptor(WithLogger(logger), WithStreamIdleWarning(10*time.Millisecond))
+
+	handler := interceptor.WrapStreamingHandler(func(context.Context, connect.StreamingHandlerConn) error {
+		panic("boom")
+	})
+	func() {
+		defer func() { _ = recover() }()
+		_ = handler(context.Background(), newTestStreamConn(connect.StreamTypeBidi, 0))
+	}()
+
+	// The idle timer must be stopped together with the panicking stream
+	time.Sleep(50 * time.Millisecond)
+	for _, record := range logRecords(t, buf) {
+		if record[slog.MessageKey] == "stream idle" {
+			t.Fatalf("expected no idle warning after the stream ended, got %v", record)
+		}
+	}
+}
@@ -0,0 +1,35 @@
+package connectlog
+
+import (
+	"context"
+	"crypto/tls"
+	"log/slog"
+)
+
+// tlsStateKey is the context key for the TLS connection state.
+type tlsStateKey struct{}
+
+// ContextWithTLSState returns a copy of ctx carrying the TLS connection
+// state of the request, for WithLogTLSPeer. Connect doesn't expose it to
+// interceptors, so set it from an HTTP middleware:
+//
+//	ctx := connectlog.ContextWithTLSState(r.Context(), r.TLS)
+//	next.ServeHTTP(w, r.WithContext(ctx))
+func ContextWithTLSState(ctx context.Context, state *tls.ConnectionState) context.Context {
+	return context.WithValue(ctx, tlsStateKey{}, state)
+}
+
+// tlsPeerAttrs returns the subject common name and serial number of the
+// verified client certificate carried by ctx, or nil without one.
+func tlsPeerAttrs(ctx context.Context) []slog.Attr {
+	state, ok := ctx.Value(tlsStateKey{}).(*tls.ConnectionState)
+	if !ok || state == nil || len(state.VerifiedChains) == 0 || len(state.VerifiedChains[0]) == 0 {
+		return nil
+	}
+
+	cert := state.VerifiedChains[0][0]
+	return []slog.Attr{
+		slog.String("peer_cn", cert.Subject.CommonName),
+		slog.String("peer_serial", cert.SerialNumber.Text(16)),
+	}
+}
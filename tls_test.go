@@ -0,0 +1,85 @@
+package connectlog
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"log/slog"
+	"math/big"
+	"testing"
+	"time"
+
+	"connectrpc.com/connect"
+)
+
+// newClientCert returns a self-signed client certificate.
+func newClientCert(t *testing.T, cn string, serial int64) *x509.Certificate {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("generate key: %v", err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(serial),
+		Subject:      pkix.Name{CommonName: cn},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("create certificate: %v", err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatalf("parse certificate: %v", err)
+	}
+	return cert
+}
+
+func TestWithLogTLSPeer(t *testing.T) {
+	cert := newClientCert(t, "billing-service", 0xbeef)
+	tests := []struct {
+		name   string
+		state  *tls.ConnectionState
+		cn     any
+		serial any
+	}{
+		{
+			name:   "verified client cert",
+			state:  &tls.ConnectionState{VerifiedChains: [][]*x509.Certificate{{cert}}},
+			cn:     "billing-service",
+			serial: "beef",
+		},
+		{name: "no client cert", state: &tls.ConnectionState{}},
+		{name: "no tls"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logger, buf := newTestLogger(slog.LevelInfo)
+			interceptor := NewLoggingInterceptor(WithLogger(logger), WithLogTLSPeer(true))
+
+			ctx := context.Background()
+			if tt.state != nil {
+				ctx = ContextWithTLSState(ctx, tt.state)
+			}
+			_, _ = interceptor.WrapUnary(func(context.Context, connect.AnyRequest) (connect.AnyResponse, error) {
+				return connect.NewResponse(&struct{}{}), nil
+			})(ctx, newTestRequest(testProcedure, &struct{}{}))
+
+			record := findRecord(t, logRecords(t, buf), "request completed")
+			if got := record["peer_cn"]; got != tt.cn {
+				t.Errorf("expected peer_cn %v, got %v", tt.cn, got)
+			}
+			if got := record["peer_serial"]; got != tt.serial {
+				t.Errorf("expected peer_serial %v, got %v", tt.serial, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,99 @@
+package connectlog
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// validationViolations extracts field violations from validation errors
+// such as protovalidate's *validate.ValidationError without depending on
+// it: any error in the chain with a ToProto method returning a message
+// with a repeated "violations" field is recognized.
+func validationViolations(err error) ([]map[string]string, bool) {
+	for ; err != nil; err = errors.Unwrap(err) {
+		msg, ok := toProto(err)
+		if !ok {
+			continue
+		}
+		if violations, ok := protoViolations(msg.ProtoReflect()); ok {
+			return violations, true
+		}
+	}
+	return nil, false
+}
+
+// toProto calls a ToProto() method on v if it has one returning a message.
+func toProto(v any) (proto.Message, bool) {
+	method := reflect.ValueOf(v).MethodByName("ToProto")
+	if !method.IsValid() || method.Type().NumIn() != 0 || method.Type().NumOut() != 1 {
+		return nil, false
+	}
+
+	msg, ok := method.Call(nil)[0].Interface().(proto.Message)
+	if !ok || msg == nil || !msg.ProtoReflect().IsValid() {
+		return nil, false
+	}
+	return msg, true
+}
+
+// protoViolations renders the repeated "violations" field of a
+// buf.validate.Violations-like message as a list of field, constraint and
+// message entries.
+func protoViolations(msg protoreflect.Message) ([]map[string]string, bool) {
+	fd := msg.Descriptor().Fields().ByName("violations")
+	if fd == nil || !fd.IsList() || fd.Message() == nil {
+		return nil, false
+	}
+
+	list := msg.Get(fd).List()
+	violations := make([]map[string]string, 0, list.Len())
+	for idx := range list.Len() {
+		violation := list.Get(idx).Message()
+		violations = append(violations, map[string]string{
+			"field":      violationField(violation),
+			"constraint": stringField(violation, "rule_id", "constraint_id"),
+			"message":    stringField(violation, "message"),
+		})
+	}
+	return violations, true
+}
+
+// violationField returns the violated field path, either from the legacy
+// field_path string or from the structured field message.
+func violationField(violation protoreflect.Message) string {
+	if path := stringField(violation, "field_path"); path != "" {
+		return path
+	}
+
+	fd := violation.Descriptor().Fields().ByName("field")
+	if fd == nil || fd.Message() == nil || !violation.Has(fd) {
+		return ""
+	}
+	path := violation.Get(fd).Message()
+	elementsFd := path.Descriptor().Fields().ByName("elements")
+	if elementsFd == nil || !elementsFd.IsList() || elementsFd.Message() == nil {
+		return ""
+	}
+
+	elements := path.Get(elementsFd).List()
+	names := make([]string, 0, elements.Len())
+	for idx := range elements.Len() {
+		names = append(names, stringField(elements.Get(idx).Message(), "field_name"))
+	}
+	return strings.Join(names, ".")
+}
+
+// stringField returns the value of the first set string field among names.
+func stringField(msg protoreflect.Message, names ...string) string {
+	for _, name := range names {
+		fd := msg.Descriptor().Fields().ByName(protoreflect.Name(name))
+		if fd != nil && fd.Kind() == protoreflect.StringKind && msg.Has(fd) {
+			return msg.Get(fd).String()
+		}
+	}
+	return ""
+}
@@ -0,0 +1,113 @@
+package connectlog
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+
+	"connectrpc.com/connect"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+// violationsDescriptor builds a message shaped like buf.validate.Violations.
+func violationsDescriptor(t *testing.T) protoreflect.MessageDescriptor {
+	t.Helper()
+
+	str := descriptorpb.FieldDescriptorProto_TYPE_STRING.Enum()
+	optional := descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL.Enum()
+	file, err := protodesc.NewFile(&descriptorpb.FileDescriptorProto{
+		Name:    proto.String("test/validate.proto"),
+		Package: proto.String("test.validate"),
+		Syntax:  proto.String("proto3"),
+		MessageType: []*descriptorpb.DescriptorProto{
+			{
+				Name: proto.String("Violation"),
+				Field: []*descriptorpb.FieldDescriptorProto{
+					{Name: proto.String("field_path"), Number: proto.Int32(1), Type: str, Label: optional, JsonName: proto.String("fieldPath")},
+					{Name: proto.String("constraint_id"), Number: proto.Int32(2), Type: str, Label: optional, JsonName: proto.String("constraintId")},
+					{Name: proto.String("message"), Number: proto.Int32(3), Type: str, Label: optional, JsonName: proto.String("message")},
+				},
+			},
+			{
+				Name: proto.String("Violations"),
+				Field: []*descriptorpb.FieldDescriptorProto{
+					{
+						Name:     proto.String("violations"),
+						Number:   proto.Int32(1),
+						Type:     descriptorpb.FieldDescriptorProto_TYPE_MESSAGE.Enum(),
+						Label:    descriptorpb.FieldDescriptorProto_LABEL_REPEATED.Enum(),
+						TypeName: proto.String(".test.validate.Violation"),
+						JsonName: proto.String("violations"),
+					},
+				},
+			},
+		},
+	}, nil)
+	if err != nil {
+		t.Fatalf("build descriptor: %v", err)
+	}
+	return file.Messages().ByName("Violations")
+}
+
+// validationError mimics protovalidate's *validate.ValidationError.
+type validationError struct {
+	violations proto.Message
+}
+
+func (e *validationError) Error() string          { return "validation error" }
+func (e *validationError) ToProto() proto.Message { return e.violations }
+
+func TestValidationViolations(t *testing.T) {
+	desc := violationsDescriptor(t)
+	violationDesc := desc.Fields().ByName("violations").Message()
+
+	msg := dynamicpb.NewMessage(desc)
+	list := msg.Mutable(desc.Fields().ByName("violations")).List()
+	for _, v := range [][3]string{
+		{"email", "string.email", "value must be a valid email address"},
+		{"age", "int32.gte", "value must be greater than or equal to 18"},
+	} {
+		violation := dynamicpb.NewMessage(violationDesc)
+		for idx, name := range []protoreflect.Name{"field_path", "constraint_id", "message"} {
+			violation.Set(violationDesc.Fields().ByName(name), protoreflect.ValueOfString(v[idx]))
+		}
+		list.Append(protoreflect.ValueOfMessage(violation))
+	}
+
+	expected := []map[string]string{
+		{"field": "email", "constraint": "string.email", "message": "value must be a valid email address"},
+		{"field": "age", "constraint": "int32.gte", "message": "value must be greater than or equal to 18"},
+	}
+
+	validationErr := &validationError{violations: msg}
+	violations, ok := validationViolations(fmt.Errorf("create user: %w", validationErr))
+	if !ok {
+		t.Fatal("expected violations to be found")
+	}
+	if !reflect.DeepEqual(violations, expected) {
+		t.Errorf("expected %v, got %v", expected, violations)
+	}
+
+	// Violations are part of the logged error group
+	err := newLoggableError(connect.NewError(connect.CodeInvalidArgument, validationErr))
+	var found bool
+	for _, attr := range err.LogValue().Group() {
+		if attr.Key == "violations" {
+			found = reflect.DeepEqual(attr.Value.Any(), expected)
+		}
+	}
+	if !found {
+		t.Error("expected violations attribute in error log value")
+	}
+}
+
+func TestValidationViolations_NotValidation(t *testing.T) {
+	if _, ok := validationViolations(errors.New("plain")); ok {
+		t.Error("expected no violations for a plain error")
+	}
+}